@@ -29,6 +29,27 @@ func startCronJob(ctx context.Context) (*cron.Cron, error) {
 		return nil, fmt.Errorf("error adding function: %v", err)
 	}
 
+	_, err = c.AddFunc("*/5 * * * *", func() {
+		errCh <- job.DeleteExpiredChats(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding function: %v", err)
+	}
+
+	_, err = c.AddFunc("0 * * * *", func() {
+		errCh <- job.DeleteProcessedUpdates(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding function: %v", err)
+	}
+
+	_, err = c.AddFunc("30 * * * *", func() {
+		errCh <- job.DeleteEmailVerifications(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding function: %v", err)
+	}
+
 	c.Start()
 	log.Println("Cron job started")
 	return c, nil
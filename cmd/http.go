@@ -9,9 +9,8 @@ import (
 	"github.com/bytedance/sonic"
 	"github.com/fidrasofyan/version-watcher-bot/internal/config"
 	"github.com/fidrasofyan/version-watcher-bot/internal/middleware"
-	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/queue"
 	"github.com/fidrasofyan/version-watcher-bot/internal/route"
-	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -19,7 +18,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/timeout"
 )
 
-func startHTTPServer(errCh chan<- error) *fiber.App {
+func startHTTPServer(q *queue.Queue, errCh chan<- error) *fiber.App {
 	app := fiber.New(fiber.Config{
 		AppName:               "Version Watcher Bot",
 		Prefork:               false,
@@ -58,37 +57,13 @@ func startHTTPServer(errCh chan<- error) *fiber.App {
 
 			// At this point, the body is a valid Telegram update
 			log.Printf("Error: %v", err)
+			body.NormalizeChannelPost()
 
-			text := "<i>Something went wrong</i>"
-			if errors.Is(err, fiber.ErrRequestTimeout) {
-				text = "<i>Request timeout</i>"
+			resp := route.ErrorResponse(c.Context(), body, err)
+			if resp == nil {
+				return c.Status(200).Send(nil)
 			}
-
-			// Delete chat
-			_ = repository.TelegramDeleteChat(c.Context(), body.CallbackQuery.From.Id)
-
-			if body.CallbackQuery.Id != "" {
-				// Answer callback query
-				_ = service.AnswerCallbackQuery(c.Context(), &service.AnswerCallbackQueryParams{
-					CallbackQueryId: body.CallbackQuery.Id,
-				})
-
-				return c.Status(200).JSON(types.TelegramResponse{
-					Method:    types.TelegramMethodEditMessageText,
-					MessageId: body.CallbackQuery.Message.MessageId,
-					ChatId:    body.CallbackQuery.Message.Chat.Id,
-					ParseMode: types.TelegramParseModeHTML,
-					Text:      text,
-				})
-			}
-
-			return c.Status(200).JSON(types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      body.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        text,
-				ReplyMarkup: types.DefaultReplyMarkup,
-			})
+			return c.Status(200).JSON(resp)
 		},
 	})
 
@@ -102,9 +77,12 @@ func startHTTPServer(errCh chan<- error) *fiber.App {
 	app.Post(
 		"/webhook",
 		middleware.Protected(),
-		timeout.NewWithContext(route.Handler(), 10*time.Second),
+		timeout.NewWithContext(route.Handler(q), 10*time.Second),
 	)
 
+	app.Get("/email/unsubscribe", route.EmailUnsubscribePage())
+	app.Post("/email/unsubscribe", route.EmailUnsubscribe())
+
 	// Not found
 	app.Use(func(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{
@@ -8,10 +8,15 @@ import (
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/commands"
 	"github.com/fidrasofyan/version-watcher-bot/internal/config"
 	"github.com/fidrasofyan/version-watcher-bot/internal/job"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/queue"
+	"github.com/fidrasofyan/version-watcher-bot/internal/route"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/gofiber/fiber/v2"
 	"github.com/robfig/cron/v3"
@@ -31,6 +36,12 @@ func main() {
 	}
 	log.Printf("Environment: %s - Runtime: %s\n", config.Cfg.AppEnv, runtime.Version())
 
+	// Load message templates
+	err = message.LoadTemplates()
+	if err != nil {
+		log.Fatalf("Error loading templates: %v", err)
+	}
+
 	// Load database
 	err = database.LoadDatabase(mainCtx)
 	if err != nil {
@@ -49,6 +60,7 @@ func main() {
 
 	var httpServer *fiber.App
 	var cronJob *cron.Cron
+	var updateQueue *queue.Queue
 
 	switch os.Args[1] {
 	case "start":
@@ -62,17 +74,7 @@ func main() {
 				}
 
 				// Set commands
-				commands := []service.Command{
-					{
-						Command:     "/start",
-						Description: "Start the bot",
-					},
-					{
-						Command:     "/watch",
-						Description: "Watch a product",
-					},
-				}
-				err = service.SetMyCommands(mainCtx, commands)
+				err = commands.Register(mainCtx)
 				if err != nil {
 					errCh <- fmt.Errorf("setting commands: %v", err)
 				}
@@ -86,8 +88,19 @@ func main() {
 				errCh <- fmt.Errorf("starting cron job: %v", err)
 			}
 
+			// Start queue, updates are processed in the webhook request without it
+			if config.Cfg.QueueWorkers > 0 {
+				q := queue.New(queue.Options{
+					Workers: config.Cfg.QueueWorkers,
+					Backend: config.Cfg.QueueBackend,
+					Timeout: 30 * time.Second,
+				}, route.Deliver)
+				q.Start(mainCtx)
+				updateQueue = q
+			}
+
 			// Start HTTP server
-			httpServer = startHTTPServer(errCh)
+			httpServer = startHTTPServer(updateQueue, errCh)
 		}()
 
 	case "populate-products":
@@ -122,6 +135,23 @@ func main() {
 			quitCh <- syscall.SIGQUIT
 		}()
 
+	case "test-webhook":
+		// Send a sample release event, e.g. to a local receiver
+		go func() {
+			if len(os.Args) < 4 {
+				errCh <- fmt.Errorf("usage: test-webhook <url> <secret>")
+				return
+			}
+			log.Printf("Sending test webhook to %s...", os.Args[2])
+			err := job.SendTestWebhook(mainCtx, os.Args[2], os.Args[3])
+			if err != nil {
+				errCh <- fmt.Errorf("sending test webhook: %v", err)
+				return
+			}
+			log.Println("DONE: sending test webhook")
+			quitCh <- syscall.SIGQUIT
+		}()
+
 	default:
 		log.Printf("Unknown command: %s", os.Args[1])
 		quitCh <- syscall.SIGQUIT
@@ -157,6 +187,12 @@ func main() {
 		}
 	}
 
+	// Stop queue, after the HTTP server so no update is queued anymore
+	if updateQueue != nil {
+		log.Println("Stopping queue...")
+		updateQueue.Stop()
+	}
+
 	// Close database
 	log.Println("Closing database...")
 	database.Pool.Close()
@@ -11,55 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createChat = `-- name: CreateChat :one
-INSERT INTO chats (id, command, step, data, created_at) 
-VALUES ($1, $2, $3, $4, $5) 
-RETURNING id, command, step, data, created_at, updated_at
+const deleteChat = `-- name: DeleteChat :exec
+DELETE FROM chats WHERE id = $1 AND user_id = $2
 `
 
-type CreateChatParams struct {
-	ID        int64
-	Command   string
-	Step      int16
-	Data      []byte
-	CreatedAt pgtype.Timestamp
+type DeleteChatParams struct {
+	ID     int64
+	UserID int64
 }
 
-func (q *Queries) CreateChat(ctx context.Context, arg *CreateChatParams) (*Chat, error) {
-	row := q.db.QueryRow(ctx, createChat,
-		arg.ID,
-		arg.Command,
-		arg.Step,
-		arg.Data,
-		arg.CreatedAt,
-	)
-	var i Chat
-	err := row.Scan(
-		&i.ID,
-		&i.Command,
-		&i.Step,
-		&i.Data,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return &i, err
+func (q *Queries) DeleteChat(ctx context.Context, arg *DeleteChatParams) error {
+	_, err := q.db.Exec(ctx, deleteChat, arg.ID, arg.UserID)
+	return err
 }
 
-const deleteChat = `-- name: DeleteChat :exec
-DELETE FROM chats WHERE id = $1
+const deleteExpiredChats = `-- name: DeleteExpiredChats :execrows
+DELETE FROM chats WHERE expires_at <= $1::timestamp
 `
 
-func (q *Queries) DeleteChat(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, deleteChat, id)
-	return err
+func (q *Queries) DeleteExpiredChats(ctx context.Context, now pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredChats, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getChat = `-- name: GetChat :one
-SELECT id, command, step, data, created_at, updated_at FROM chats WHERE id = $1 LIMIT 1
+SELECT id, command, step, data, created_at, updated_at, user_id, expires_at FROM chats 
+WHERE id = $1 
+AND user_id = $2 
+AND expires_at > $3::timestamp 
+LIMIT 1
 `
 
-func (q *Queries) GetChat(ctx context.Context, id int64) (*Chat, error) {
-	row := q.db.QueryRow(ctx, getChat, id)
+type GetChatParams struct {
+	ID     int64
+	UserID int64
+	Now    pgtype.Timestamp
+}
+
+func (q *Queries) GetChat(ctx context.Context, arg *GetChatParams) (*Chat, error) {
+	row := q.db.QueryRow(ctx, getChat, arg.ID, arg.UserID, arg.Now)
 	var i Chat
 	err := row.Scan(
 		&i.ID,
@@ -68,43 +61,40 @@ func (q *Queries) GetChat(ctx context.Context, id int64) (*Chat, error) {
 		&i.Data,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
 
-const isChatExists = `-- name: IsChatExists :one
-SELECT EXISTS(SELECT 1 FROM chats WHERE id = $1 LIMIT 1)
-`
-
-func (q *Queries) IsChatExists(ctx context.Context, id int64) (bool, error) {
-	row := q.db.QueryRow(ctx, isChatExists, id)
-	var exists bool
-	err := row.Scan(&exists)
-	return exists, err
-}
-
-const updateChat = `-- name: UpdateChat :one
-UPDATE chats
-SET command = $1, step = $2, data = $3, updated_at = $4
-WHERE id = $5
-RETURNING id, command, step, data, created_at, updated_at
+const upsertChat = `-- name: UpsertChat :one
+INSERT INTO chats (id, user_id, command, step, data, expires_at, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+ON CONFLICT (id, user_id) DO UPDATE
+SET command = EXCLUDED.command, step = EXCLUDED.step, data = EXCLUDED.data, 
+  expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.created_at
+RETURNING id, command, step, data, created_at, updated_at, user_id, expires_at
 `
 
-type UpdateChatParams struct {
+type UpsertChatParams struct {
+	ID        int64
+	UserID    int64
 	Command   string
 	Step      int16
 	Data      []byte
-	UpdatedAt pgtype.Timestamp
-	ID        int64
+	ExpiresAt pgtype.Timestamp
+	CreatedAt pgtype.Timestamp
 }
 
-func (q *Queries) UpdateChat(ctx context.Context, arg *UpdateChatParams) (*Chat, error) {
-	row := q.db.QueryRow(ctx, updateChat,
+func (q *Queries) UpsertChat(ctx context.Context, arg *UpsertChatParams) (*Chat, error) {
+	row := q.db.QueryRow(ctx, upsertChat,
+		arg.ID,
+		arg.UserID,
 		arg.Command,
 		arg.Step,
 		arg.Data,
-		arg.UpdatedAt,
-		arg.ID,
+		arg.ExpiresAt,
+		arg.CreatedAt,
 	)
 	var i Chat
 	err := row.Scan(
@@ -114,6 +104,8 @@ func (q *Queries) UpdateChat(ctx context.Context, arg *UpdateChatParams) (*Chat,
 		&i.Data,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- chats: conversation state is keyed by (chat, user) so several members
+-- of a group can run their own flows at the same time
+ALTER TABLE chats ADD COLUMN user_id bigint;
+UPDATE chats SET user_id = id;
+ALTER TABLE chats ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE chats DROP CONSTRAINT chats_pkey;
+ALTER TABLE chats ADD PRIMARY KEY (id, user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM chats WHERE id <> user_id;
+ALTER TABLE chats DROP CONSTRAINT chats_pkey;
+ALTER TABLE chats DROP COLUMN user_id;
+ALTER TABLE chats ADD PRIMARY KEY (id);
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- watch_list_channels: channels that mirror the notifications of a watch list
+CREATE TABLE watch_list_channels (
+  id serial PRIMARY KEY,
+  chat_id bigint NOT NULL,
+  channel_id bigint NOT NULL,
+  channel_title varchar(200) NOT NULL,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_watch_list_channels_chat_id ON watch_list_channels(chat_id);
+CREATE UNIQUE INDEX idx_watch_list_channels_chat_id_channel_id ON watch_list_channels(chat_id, channel_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE watch_list_channels;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- webhooks: HTTP endpoints that receive release events of a watch list
+CREATE TABLE webhooks (
+  id serial PRIMARY KEY,
+  chat_id bigint NOT NULL,
+  url varchar(2048) NOT NULL,
+  secret varchar(100) NOT NULL,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_webhooks_chat_id ON webhooks(chat_id);
+CREATE UNIQUE INDEX idx_webhooks_chat_id_url ON webhooks(chat_id, url);
+
+-- webhook_deliveries: one row per delivery attempt
+CREATE TABLE webhook_deliveries (
+  id bigserial PRIMARY KEY,
+  webhook_id integer NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  event varchar(50) NOT NULL,
+  payload jsonb NOT NULL,
+  attempt smallint NOT NULL,
+  status_code integer,
+  error text,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+-- +goose StatementEnd
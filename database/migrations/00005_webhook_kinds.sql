@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- webhooks: kind of payload the endpoint expects (generic, slack, discord)
+ALTER TABLE webhooks ADD COLUMN kind varchar(20) NOT NULL DEFAULT 'generic';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhooks DROP COLUMN kind;
+-- +goose StatementEnd
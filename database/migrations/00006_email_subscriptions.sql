@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- email_subscriptions: email addresses that receive the notifications of a watch list
+CREATE TABLE email_subscriptions (
+  id serial PRIMARY KEY,
+  chat_id bigint NOT NULL,
+  email varchar(320) NOT NULL,
+  verification_code varchar(10) NOT NULL,
+  unsubscribe_token varchar(64) NOT NULL,
+  verified_at timestamp,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_email_subscriptions_chat_id ON email_subscriptions(chat_id);
+CREATE UNIQUE INDEX idx_email_subscriptions_chat_id_email ON email_subscriptions(chat_id, email);
+CREATE UNIQUE INDEX idx_email_subscriptions_unsubscribe_token ON email_subscriptions(unsubscribe_token);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE email_subscriptions;
+-- +goose StatementEnd
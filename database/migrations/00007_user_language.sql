@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- users: language reported by Telegram and language chosen with /language
+ALTER TABLE users ADD COLUMN language_code varchar(10);
+ALTER TABLE users ADD COLUMN language varchar(10);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN language;
+ALTER TABLE users DROP COLUMN language_code;
+-- +goose StatementEnd
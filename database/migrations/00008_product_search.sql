@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- products: fuzzy search matches names as well as labels
+CREATE INDEX idx_products_name_trgm ON products USING gin (name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_products_name_trgm;
+-- +goose StatementEnd
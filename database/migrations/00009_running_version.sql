@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- watch_lists: version of the product the chat actually runs, set with /running
+ALTER TABLE watch_lists ADD COLUMN running_version varchar(50);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE watch_lists DROP COLUMN running_version;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- collections: curated lists of products owned by a user, shared with /start col_<id>
+CREATE TABLE collections (
+  id serial PRIMARY KEY,
+  owner_id bigint NOT NULL,
+  name varchar(100) NOT NULL,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_collections_owner_id ON collections(owner_id);
+
+CREATE TABLE collection_products (
+  id serial PRIMARY KEY,
+  collection_id integer NOT NULL REFERENCES collections(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  created_at timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_collection_products_collection_id_product_id ON collection_products(collection_id, product_id);
+
+-- collection_subscriptions: chats whose watch list follows the products added to a collection
+CREATE TABLE collection_subscriptions (
+  id serial PRIMARY KEY,
+  collection_id integer NOT NULL REFERENCES collections(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  chat_id bigint NOT NULL,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_collection_subscriptions_chat_id ON collection_subscriptions(chat_id);
+CREATE UNIQUE INDEX idx_collection_subscriptions_collection_id_chat_id ON collection_subscriptions(collection_id, chat_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE collection_subscriptions;
+DROP TABLE collection_products;
+DROP TABLE collections;
+-- +goose StatementEnd
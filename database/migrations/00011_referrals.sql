@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- users: user whose deep link brought the user to the bot
+ALTER TABLE users ADD COLUMN referred_by bigint;
+
+CREATE INDEX idx_users_referred_by ON users(referred_by);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_users_referred_by;
+ALTER TABLE users DROP COLUMN referred_by;
+-- +goose StatementEnd
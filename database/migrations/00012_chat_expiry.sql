@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- chats: conversation state expires, expired rows are ignored and cleared by the janitor
+ALTER TABLE chats ADD COLUMN expires_at timestamp;
+UPDATE chats SET expires_at = COALESCE(updated_at, created_at) + interval '30 minutes';
+ALTER TABLE chats ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX idx_chats_expires_at ON chats(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chats_expires_at;
+ALTER TABLE chats DROP COLUMN expires_at;
+-- +goose StatementEnd
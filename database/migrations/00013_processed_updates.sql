@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- processed_updates: Telegram updates already handled, so redelivered ones are skipped
+CREATE TABLE processed_updates (
+  update_id bigint PRIMARY KEY,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_processed_updates_created_at ON processed_updates(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE processed_updates;
+-- +goose StatementEnd
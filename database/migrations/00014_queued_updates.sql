@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- queued_updates: Telegram updates waiting to be processed, when the queue is backed by Postgres
+CREATE TABLE queued_updates (
+  id bigserial PRIMARY KEY,
+  payload jsonb NOT NULL,
+  dispatched_at timestamp,
+  created_at timestamp NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE queued_updates;
+-- +goose StatementEnd
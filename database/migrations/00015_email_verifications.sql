@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- email_verifications: confirmation codes sent, to limit how often an address receives them
+CREATE TABLE email_verifications (
+  id bigserial PRIMARY KEY,
+  chat_id bigint NOT NULL,
+  email varchar(320) NOT NULL,
+  created_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_email_verifications_email_created_at ON email_verifications(email, created_at);
+CREATE INDEX idx_email_verifications_chat_id_created_at ON email_verifications(chat_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE email_verifications;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- processed_updates: an update is claimed while it's processed and completed once replied to,
+-- claims never completed (e.g. after a crash) can be taken over
+ALTER TABLE processed_updates ADD COLUMN completed_at timestamp;
+UPDATE processed_updates SET completed_at = created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE processed_updates DROP COLUMN completed_at;
+-- +goose StatementEnd
@@ -15,6 +15,46 @@ type Chat struct {
 	Data      []byte
 	CreatedAt pgtype.Timestamp
 	UpdatedAt pgtype.Timestamp
+	UserID    int64
+	ExpiresAt pgtype.Timestamp
+}
+
+type Collection struct {
+	ID        int32
+	OwnerID   int64
+	Name      string
+	CreatedAt pgtype.Timestamp
+}
+
+type CollectionProduct struct {
+	ID           int32
+	CollectionID int32
+	ProductID    int32
+	CreatedAt    pgtype.Timestamp
+}
+
+type CollectionSubscription struct {
+	ID           int32
+	CollectionID int32
+	ChatID       int64
+	CreatedAt    pgtype.Timestamp
+}
+
+type EmailSubscription struct {
+	ID               int32
+	ChatID           int64
+	Email            string
+	VerificationCode string
+	UnsubscribeToken string
+	VerifiedAt       pgtype.Timestamp
+	CreatedAt        pgtype.Timestamp
+}
+
+type EmailVerification struct {
+	ID        int64
+	ChatID    int64
+	Email     string
+	CreatedAt pgtype.Timestamp
 }
 
 type Product struct {
@@ -28,6 +68,12 @@ type Product struct {
 	UpdatedAt pgtype.Timestamp
 }
 
+type ProcessedUpdate struct {
+	UpdateID    int64
+	CreatedAt   pgtype.Timestamp
+	CompletedAt pgtype.Timestamp
+}
+
 type ProductVersion struct {
 	ID                 int32
 	ProductID          int32
@@ -41,17 +87,56 @@ type ProductVersion struct {
 	CreatedAt          pgtype.Timestamp
 }
 
+type QueuedUpdate struct {
+	ID           int64
+	Payload      []byte
+	DispatchedAt pgtype.Timestamp
+	CreatedAt    pgtype.Timestamp
+}
+
 type User struct {
-	ID        int64
-	Username  *string
-	FirstName *string
-	LastName  *string
-	CreatedAt pgtype.Timestamp
+	ID           int64
+	Username     *string
+	FirstName    *string
+	LastName     *string
+	CreatedAt    pgtype.Timestamp
+	LanguageCode *string
+	Language     *string
+	ReferredBy   *int64
 }
 
 type WatchList struct {
+	ID             int32
+	ChatID         int64
+	ProductID      int32
+	CreatedAt      pgtype.Timestamp
+	RunningVersion *string
+}
+
+type WatchListChannel struct {
+	ID           int32
+	ChatID       int64
+	ChannelID    int64
+	ChannelTitle string
+	CreatedAt    pgtype.Timestamp
+}
+
+type Webhook struct {
 	ID        int32
 	ChatID    int64
-	ProductID int32
+	Url       string
+	Secret    string
 	CreatedAt pgtype.Timestamp
+	Kind      string
+}
+
+type WebhookDelivery struct {
+	ID         int64
+	WebhookID  int32
+	Event      string
+	Payload    []byte
+	Attempt    int16
+	StatusCode *int32
+	Error      *string
+	CreatedAt  pgtype.Timestamp
 }
@@ -54,6 +54,38 @@ func (q *Queries) CreateProductVersion(ctx context.Context, arg *CreateProductVe
 	return err
 }
 
+const getCycleVersions = `-- name: GetCycleVersions :many
+SELECT version
+FROM product_versions
+WHERE product_id = $1
+AND release_name = $2
+`
+
+type GetCycleVersionsParams struct {
+	ProductID   int32
+	ReleaseName string
+}
+
+func (q *Queries) GetCycleVersions(ctx context.Context, arg *GetCycleVersionsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getCycleVersions, arg.ProductID, arg.ReleaseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		items = append(items, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getDistinctProductIdsFromProductVersionsByCreatedAt = `-- name: GetDistinctProductIdsFromProductVersionsByCreatedAt :many
 SELECT DISTINCT product_id
 FROM product_versions
@@ -79,3 +111,60 @@ func (q *Queries) GetDistinctProductIdsFromProductVersionsByCreatedAt(ctx contex
 	}
 	return items, nil
 }
+
+const getProductVersionHistory = `-- name: GetProductVersionHistory :many
+SELECT 
+  release_label, 
+  version, 
+  version_release_date, 
+  version_release_link, 
+  created_at,
+  COUNT(*) OVER() AS total
+FROM product_versions
+WHERE product_id = $1
+ORDER BY release_date DESC NULLS LAST, release_name DESC, version_release_date DESC NULLS LAST, id DESC
+LIMIT $2::int
+OFFSET $3::int
+`
+
+type GetProductVersionHistoryParams struct {
+	ProductID  int32
+	PageSize   int32
+	PageOffset int32
+}
+
+type GetProductVersionHistoryRow struct {
+	ReleaseLabel       string
+	Version            string
+	VersionReleaseDate pgtype.Timestamp
+	VersionReleaseLink *string
+	CreatedAt          pgtype.Timestamp
+	Total              int64
+}
+
+func (q *Queries) GetProductVersionHistory(ctx context.Context, arg *GetProductVersionHistoryParams) ([]*GetProductVersionHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getProductVersionHistory, arg.ProductID, arg.PageSize, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetProductVersionHistoryRow{}
+	for rows.Next() {
+		var i GetProductVersionHistoryRow
+		if err := rows.Scan(
+			&i.ReleaseLabel,
+			&i.Version,
+			&i.VersionReleaseDate,
+			&i.VersionReleaseLink,
+			&i.CreatedAt,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
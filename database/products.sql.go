@@ -12,7 +12,7 @@ import (
 )
 
 const getProductById = `-- name: GetProductById :one
-SELECT id, name, label, category, api_url, created_at
+SELECT id, name, label, category, api_url, eol_url, created_at
 FROM products WHERE id = $1 LIMIT 1
 `
 
@@ -22,6 +22,7 @@ type GetProductByIdRow struct {
 	Label     string
 	Category  string
 	ApiUrl    string
+	EolUrl    string
 	CreatedAt pgtype.Timestamp
 }
 
@@ -34,40 +35,102 @@ func (q *Queries) GetProductById(ctx context.Context, id int32) (*GetProductById
 		&i.Label,
 		&i.Category,
 		&i.ApiUrl,
+		&i.EolUrl,
 		&i.CreatedAt,
 	)
 	return &i, err
 }
 
-const getProductsByLabel = `-- name: GetProductsByLabel :many
-SELECT id, name, label, api_url
-FROM products 
-WHERE label ILIKE $1 
-ORDER BY name ASC NULLS LAST
-LIMIT 100
+const getProductByNameOrLabel = `-- name: GetProductByNameOrLabel :one
+SELECT id, name, label
+FROM products
+WHERE name = lower($1::text)
+OR lower(label) = lower($1::text)
+ORDER BY name = lower($1::text) DESC
+LIMIT 1
 `
 
-type GetProductsByLabelRow struct {
-	ID     int32
-	Name   string
-	Label  string
-	ApiUrl string
+type GetProductByNameOrLabelRow struct {
+	ID    int32
+	Name  string
+	Label string
+}
+
+func (q *Queries) GetProductByNameOrLabel(ctx context.Context, keyword string) (*GetProductByNameOrLabelRow, error) {
+	row := q.db.QueryRow(ctx, getProductByNameOrLabel, keyword)
+	var i GetProductByNameOrLabelRow
+	err := row.Scan(&i.ID, &i.Name, &i.Label)
+	return &i, err
+}
+
+const getProductCategories = `-- name: GetProductCategories :many
+SELECT category, COUNT(*) AS total
+FROM products
+GROUP BY category
+ORDER BY category ASC
+`
+
+type GetProductCategoriesRow struct {
+	Category string
+	Total    int64
+}
+
+func (q *Queries) GetProductCategories(ctx context.Context) ([]*GetProductCategoriesRow, error) {
+	rows, err := q.db.Query(ctx, getProductCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetProductCategoriesRow{}
+	for rows.Next() {
+		var i GetProductCategoriesRow
+		if err := rows.Scan(&i.Category, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductsByCategory = `-- name: GetProductsByCategory :many
+SELECT id, name, label, COUNT(*) OVER() AS total
+FROM products
+WHERE category = $1
+ORDER BY label ASC, id ASC
+LIMIT $2::int
+OFFSET $3::int
+`
+
+type GetProductsByCategoryParams struct {
+	Category   string
+	PageSize   int32
+	PageOffset int32
 }
 
-func (q *Queries) GetProductsByLabel(ctx context.Context, label string) ([]*GetProductsByLabelRow, error) {
-	rows, err := q.db.Query(ctx, getProductsByLabel, label)
+type GetProductsByCategoryRow struct {
+	ID    int32
+	Name  string
+	Label string
+	Total int64
+}
+
+func (q *Queries) GetProductsByCategory(ctx context.Context, arg *GetProductsByCategoryParams) ([]*GetProductsByCategoryRow, error) {
+	rows, err := q.db.Query(ctx, getProductsByCategory, arg.Category, arg.PageSize, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*GetProductsByLabelRow{}
+	items := []*GetProductsByCategoryRow{}
 	for rows.Next() {
-		var i GetProductsByLabelRow
+		var i GetProductsByCategoryRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
 			&i.Label,
-			&i.ApiUrl,
+			&i.Total,
 		); err != nil {
 			return nil, err
 		}
@@ -79,13 +142,48 @@ func (q *Queries) GetProductsByLabel(ctx context.Context, label string) ([]*GetP
 	return items, nil
 }
 
+const getProductsByNames = `-- name: GetProductsByNames :many
+SELECT id, name, label
+FROM products
+WHERE name = ANY($1::text[])
+ORDER BY label ASC
+`
+
+type GetProductsByNamesRow struct {
+	ID    int32
+	Name  string
+	Label string
+}
+
+func (q *Queries) GetProductsByNames(ctx context.Context, names []string) ([]*GetProductsByNamesRow, error) {
+	rows, err := q.db.Query(ctx, getProductsByNames, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetProductsByNamesRow{}
+	for rows.Next() {
+		var i GetProductsByNamesRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Label); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProductsWithNewReleases = `-- name: GetProductsWithNewReleases :many
 SELECT 
   p.id AS product_id,
+  p.name AS product_name,
   p.label AS product_label, 
   p.eol_url AS product_eol_url,
   json_agg(
     json_build_object(
+      'release_name', pv.release_name,
       'release_label', pv.release_label,
       'version', pv.version,
       'version_release_date', pv.version_release_date,
@@ -94,7 +192,7 @@ SELECT
   ) AS product_versions
 FROM products p
 JOIN LATERAL (
-  SELECT pv.release_label, pv.version, pv.version_release_date, pv.version_release_link
+  SELECT pv.release_name, pv.release_label, pv.version, pv.version_release_date, pv.version_release_link
   FROM product_versions pv
   WHERE pv.created_at = $1
   AND pv.product_id = p.id
@@ -113,6 +211,7 @@ type GetProductsWithNewReleasesParams struct {
 
 type GetProductsWithNewReleasesRow struct {
 	ProductID       int32
+	ProductName     string
 	ProductLabel    string
 	ProductEolUrl   string
 	ProductVersions []byte
@@ -129,6 +228,7 @@ func (q *Queries) GetProductsWithNewReleases(ctx context.Context, arg *GetProduc
 		var i GetProductsWithNewReleasesRow
 		if err := rows.Scan(
 			&i.ProductID,
+			&i.ProductName,
 			&i.ProductLabel,
 			&i.ProductEolUrl,
 			&i.ProductVersions,
@@ -143,28 +243,28 @@ func (q *Queries) GetProductsWithNewReleases(ctx context.Context, arg *GetProduc
 	return items, nil
 }
 
-const getWatchedProductByName = `-- name: GetWatchedProductByName :one
+const getWatchedProductById = `-- name: GetWatchedProductById :one
 SELECT p.id, p.label
 FROM products p
 INNER JOIN watch_lists wl ON wl.product_id = p.id
-WHERE p.name = $1
+WHERE p.id = $1
 AND wl.chat_id = $2
 LIMIT 1
 `
 
-type GetWatchedProductByNameParams struct {
-	Name   string
+type GetWatchedProductByIdParams struct {
+	ID     int32
 	ChatID int64
 }
 
-type GetWatchedProductByNameRow struct {
+type GetWatchedProductByIdRow struct {
 	ID    int32
 	Label string
 }
 
-func (q *Queries) GetWatchedProductByName(ctx context.Context, arg *GetWatchedProductByNameParams) (*GetWatchedProductByNameRow, error) {
-	row := q.db.QueryRow(ctx, getWatchedProductByName, arg.Name, arg.ChatID)
-	var i GetWatchedProductByNameRow
+func (q *Queries) GetWatchedProductById(ctx context.Context, arg *GetWatchedProductByIdParams) (*GetWatchedProductByIdRow, error) {
+	row := q.db.QueryRow(ctx, getWatchedProductById, arg.ID, arg.ChatID)
+	var i GetWatchedProductByIdRow
 	err := row.Scan(&i.ID, &i.Label)
 	return &i, err
 }
@@ -202,6 +302,67 @@ func (q *Queries) GetWatchedProducts(ctx context.Context) ([]*GetWatchedProducts
 	return items, nil
 }
 
+const searchProducts = `-- name: SearchProducts :many
+SELECT id, name, label, api_url, eol_url, COUNT(*) OVER() AS total
+FROM products 
+WHERE label ILIKE '%' || $1::text || '%' 
+OR name ILIKE '%' || $1::text || '%' 
+OR label % $1::text
+OR name % $1::text
+OR $1::text <% label
+OR $1::text <% name
+ORDER BY GREATEST(
+  similarity(label, $1::text),
+  similarity(name, $1::text),
+  word_similarity($1::text, label),
+  word_similarity($1::text, name)
+) DESC, name ASC
+LIMIT $2::int
+OFFSET $3::int
+`
+
+type SearchProductsParams struct {
+	Keyword    string
+	PageSize   int32
+	PageOffset int32
+}
+
+type SearchProductsRow struct {
+	ID     int32
+	Name   string
+	Label  string
+	ApiUrl string
+	EolUrl string
+	Total  int64
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg *SearchProductsParams) ([]*SearchProductsRow, error) {
+	rows, err := q.db.Query(ctx, searchProducts, arg.Keyword, arg.PageSize, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*SearchProductsRow{}
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Label,
+			&i.ApiUrl,
+			&i.EolUrl,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const upsertProduct = `-- name: UpsertProduct :exec
 INSERT INTO products (name, label, category, api_url, eol_url, created_at) 
 VALUES ($1, $2, $3, $4, $5, $6)
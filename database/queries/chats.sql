@@ -1,19 +1,20 @@
--- name: IsChatExists :one
-SELECT EXISTS(SELECT 1 FROM chats WHERE id = $1 LIMIT 1);
-
 -- name: GetChat :one
-SELECT * FROM chats WHERE id = $1 LIMIT 1;
-
--- name: CreateChat :one
-INSERT INTO chats (id, command, step, data, created_at) 
-VALUES ($1, $2, $3, $4, $5) 
-RETURNING *;
+SELECT * FROM chats 
+WHERE id = sqlc.arg(id) 
+AND user_id = sqlc.arg(user_id) 
+AND expires_at > sqlc.arg(now)::timestamp 
+LIMIT 1;
 
--- name: UpdateChat :one
-UPDATE chats
-SET command = $1, step = $2, data = $3, updated_at = $4
-WHERE id = $5
+-- name: UpsertChat :one
+INSERT INTO chats (id, user_id, command, step, data, expires_at, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+ON CONFLICT (id, user_id) DO UPDATE
+SET command = EXCLUDED.command, step = EXCLUDED.step, data = EXCLUDED.data, 
+  expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.created_at
 RETURNING *;
 
 -- name: DeleteChat :exec
-DELETE FROM chats WHERE id = $1;
\ No newline at end of file
+DELETE FROM chats WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredChats :execrows
+DELETE FROM chats WHERE expires_at <= sqlc.arg(now)::timestamp;
\ No newline at end of file
@@ -0,0 +1,64 @@
+-- name: CreateCollection :one
+INSERT INTO collections (owner_id, name, created_at) 
+VALUES ($1, $2, $3) 
+RETURNING *;
+
+-- name: GetCollection :one
+SELECT * FROM collections WHERE id = $1 LIMIT 1;
+
+-- name: GetCollectionsByOwner :many
+SELECT 
+  c.id, 
+  c.name,
+  (SELECT COUNT(*) FROM collection_products cp WHERE cp.collection_id = c.id) AS total_products,
+  (SELECT COUNT(*) FROM collection_subscriptions cs WHERE cs.collection_id = c.id) AS total_subscribers
+FROM collections c
+WHERE c.owner_id = $1
+ORDER BY c.name ASC;
+
+-- name: DeleteCollection :execrows
+DELETE FROM collections 
+WHERE id = $1 
+AND owner_id = $2;
+
+-- name: AddCollectionProducts :exec
+INSERT INTO collection_products (collection_id, product_id, created_at)
+SELECT sqlc.arg(collection_id)::int, unnest(sqlc.arg(product_ids)::int[]), sqlc.arg(created_at)::timestamp
+ON CONFLICT (collection_id, product_id) DO NOTHING;
+
+-- name: DeleteCollectionProduct :execrows
+DELETE FROM collection_products 
+WHERE collection_id = $1 
+AND product_id = $2;
+
+-- name: GetCollectionProducts :many
+SELECT p.id, p.label
+FROM collection_products cp
+JOIN products p ON cp.product_id = p.id
+WHERE cp.collection_id = $1
+ORDER BY p.label ASC;
+
+-- name: CreateCollectionSubscription :exec
+INSERT INTO collection_subscriptions (collection_id, chat_id, created_at) 
+VALUES ($1, $2, $3) 
+ON CONFLICT (collection_id, chat_id) DO NOTHING;
+
+-- name: DeleteCollectionSubscription :execrows
+DELETE FROM collection_subscriptions 
+WHERE collection_id = $1 
+AND chat_id = $2;
+
+-- name: GetCollectionSubscriptionsByChat :many
+SELECT c.id, c.name
+FROM collection_subscriptions cs
+JOIN collections c ON cs.collection_id = c.id
+WHERE cs.chat_id = $1
+ORDER BY c.name ASC;
+
+-- name: PropagateCollectionProducts :exec
+INSERT INTO watch_lists (chat_id, product_id, created_at)
+SELECT cs.chat_id, p.id, sqlc.arg(created_at)::timestamp
+FROM collection_subscriptions cs
+CROSS JOIN unnest(sqlc.arg(product_ids)::int[]) AS p(id)
+WHERE cs.collection_id = sqlc.arg(collection_id)::int
+ON CONFLICT (chat_id, product_id) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,39 @@
+-- name: CreateEmailSubscription :one
+INSERT INTO email_subscriptions (chat_id, email, verification_code, unsubscribe_token, created_at) 
+VALUES ($1, $2, $3, $4, $5) 
+ON CONFLICT (chat_id, email) DO UPDATE SET 
+  verification_code = excluded.verification_code
+RETURNING *;
+
+-- name: GetEmailSubscription :one
+SELECT * FROM email_subscriptions 
+WHERE id = $1 
+AND chat_id = $2 
+LIMIT 1;
+
+-- name: GetEmailSubscriptionsByChat :many
+SELECT * FROM email_subscriptions 
+WHERE chat_id = $1
+ORDER BY email ASC;
+
+-- name: GetVerifiedEmailSubscriptions :many
+SELECT * FROM email_subscriptions 
+WHERE verified_at IS NOT NULL;
+
+-- name: CountEmailSubscriptions :one
+SELECT COUNT(*) FROM email_subscriptions WHERE chat_id = $1 AND email <> $2;
+
+-- name: VerifyEmailSubscription :exec
+UPDATE email_subscriptions
+SET verified_at = $1
+WHERE id = $2;
+
+-- name: DeleteEmailSubscription :exec
+DELETE FROM email_subscriptions 
+WHERE id = $1 
+AND chat_id = $2;
+
+-- name: DeleteEmailSubscriptionByUnsubscribeToken :one
+DELETE FROM email_subscriptions 
+WHERE unsubscribe_token = $1
+RETURNING email;
\ No newline at end of file
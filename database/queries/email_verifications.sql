@@ -0,0 +1,16 @@
+-- name: CreateEmailVerification :exec
+INSERT INTO email_verifications (chat_id, email, created_at) 
+VALUES ($1, $2, $3);
+
+-- name: CountEmailVerificationsByEmail :one
+SELECT COUNT(*) FROM email_verifications 
+WHERE email = $1 
+AND created_at > $2;
+
+-- name: CountEmailVerificationsByChat :one
+SELECT COUNT(*) FROM email_verifications 
+WHERE chat_id = $1 
+AND created_at > $2;
+
+-- name: DeleteEmailVerifications :execrows
+DELETE FROM email_verifications WHERE created_at < $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: LockChat :exec
+SELECT pg_advisory_xact_lock(sqlc.arg(key)::bigint);
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: ClaimProcessedUpdate :execrows
+INSERT INTO processed_updates (update_id, created_at) 
+VALUES (sqlc.arg(update_id), sqlc.arg(created_at)) 
+ON CONFLICT (update_id) DO UPDATE SET 
+  created_at = excluded.created_at
+WHERE processed_updates.completed_at IS NULL 
+AND processed_updates.created_at < sqlc.arg(stale_before)::timestamp;
+
+-- name: CompleteProcessedUpdate :exec
+UPDATE processed_updates
+SET completed_at = $1
+WHERE update_id = $2;
+
+-- name: DeleteProcessedUpdate :exec
+DELETE FROM processed_updates 
+WHERE update_id = $1 
+AND completed_at IS NULL;
+
+-- name: DeleteProcessedUpdates :execrows
+DELETE FROM processed_updates WHERE created_at < $1;
\ No newline at end of file
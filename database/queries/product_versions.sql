@@ -18,3 +18,22 @@ SELECT DISTINCT product_id
 FROM product_versions
 WHERE created_at = $1;
 
+-- name: GetCycleVersions :many
+SELECT version
+FROM product_versions
+WHERE product_id = $1
+AND release_name = $2;
+
+-- name: GetProductVersionHistory :many
+SELECT 
+  release_label, 
+  version, 
+  version_release_date, 
+  version_release_link, 
+  created_at,
+  COUNT(*) OVER() AS total
+FROM product_versions
+WHERE product_id = sqlc.arg(product_id)
+ORDER BY release_date DESC NULLS LAST, release_name DESC, version_release_date DESC NULLS LAST, id DESC
+LIMIT sqlc.arg(page_size)::int
+OFFSET sqlc.arg(page_offset)::int;
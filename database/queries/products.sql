@@ -10,21 +10,60 @@ ON CONFLICT(name) DO UPDATE SET
   updated_at = excluded.created_at;
 
 -- name: GetProductById :one
-SELECT id, name, label, category, api_url, created_at
+SELECT id, name, label, category, api_url, eol_url, created_at
 FROM products WHERE id = $1 LIMIT 1;
 
--- name: GetProductsByLabel :many
-SELECT id, name, label, api_url
+-- name: GetProductByNameOrLabel :one
+SELECT id, name, label
+FROM products
+WHERE name = lower(sqlc.arg(keyword)::text)
+OR lower(label) = lower(sqlc.arg(keyword)::text)
+ORDER BY name = lower(sqlc.arg(keyword)::text) DESC
+LIMIT 1;
+
+-- name: GetProductsByNames :many
+SELECT id, name, label
+FROM products
+WHERE name = ANY(sqlc.arg(names)::text[])
+ORDER BY label ASC;
+
+-- name: SearchProducts :many
+SELECT id, name, label, api_url, eol_url, COUNT(*) OVER() AS total
 FROM products 
-WHERE label ILIKE $1 
-ORDER BY name ASC NULLS LAST
-LIMIT 100;
+WHERE label ILIKE '%' || sqlc.arg(keyword)::text || '%' 
+OR name ILIKE '%' || sqlc.arg(keyword)::text || '%' 
+OR label % sqlc.arg(keyword)::text
+OR name % sqlc.arg(keyword)::text
+OR sqlc.arg(keyword)::text <% label
+OR sqlc.arg(keyword)::text <% name
+ORDER BY GREATEST(
+  similarity(label, sqlc.arg(keyword)::text),
+  similarity(name, sqlc.arg(keyword)::text),
+  word_similarity(sqlc.arg(keyword)::text, label),
+  word_similarity(sqlc.arg(keyword)::text, name)
+) DESC, name ASC
+LIMIT sqlc.arg(page_size)::int
+OFFSET sqlc.arg(page_offset)::int;
+
+-- name: GetProductCategories :many
+SELECT category, COUNT(*) AS total
+FROM products
+GROUP BY category
+ORDER BY category ASC;
+
+-- name: GetProductsByCategory :many
+SELECT id, name, label, COUNT(*) OVER() AS total
+FROM products
+WHERE category = sqlc.arg(category)
+ORDER BY label ASC, id ASC
+LIMIT sqlc.arg(page_size)::int
+OFFSET sqlc.arg(page_offset)::int;
 
--- name: GetWatchedProductByName :one
+-- name: GetWatchedProductById :one
 SELECT p.id, p.label
 FROM products p
 INNER JOIN watch_lists wl ON wl.product_id = p.id
-WHERE p.name = $1
+WHERE p.id = $1
 AND wl.chat_id = $2
 LIMIT 1;
 
@@ -37,10 +76,12 @@ GROUP BY products.id;
 -- name: GetProductsWithNewReleases :many
 SELECT 
   p.id AS product_id,
+  p.name AS product_name,
   p.label AS product_label, 
   p.eol_url AS product_eol_url,
   json_agg(
     json_build_object(
+      'release_name', pv.release_name,
       'release_label', pv.release_label,
       'version', pv.version,
       'version_release_date', pv.version_release_date,
@@ -49,7 +90,7 @@ SELECT
   ) AS product_versions
 FROM products p
 JOIN LATERAL (
-  SELECT pv.release_label, pv.version, pv.version_release_date, pv.version_release_link
+  SELECT pv.release_name, pv.release_label, pv.version, pv.version_release_date, pv.version_release_link
   FROM product_versions pv
   WHERE pv.created_at = $1
   AND pv.product_id = p.id
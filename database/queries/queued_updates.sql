@@ -0,0 +1,23 @@
+-- name: CreateQueuedUpdate :exec
+INSERT INTO queued_updates (payload, created_at) 
+VALUES ($1, $2);
+
+-- name: DispatchQueuedUpdates :many
+UPDATE queued_updates
+SET dispatched_at = sqlc.arg(dispatched_at)
+WHERE id IN (
+  SELECT id FROM queued_updates
+  WHERE dispatched_at IS NULL
+  ORDER BY id ASC
+  LIMIT sqlc.arg(size)::int
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, payload;
+
+-- name: ResetQueuedUpdates :exec
+UPDATE queued_updates 
+SET dispatched_at = NULL 
+WHERE dispatched_at < $1;
+
+-- name: DeleteQueuedUpdate :exec
+DELETE FROM queued_updates WHERE id = $1;
\ No newline at end of file
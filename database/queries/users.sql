@@ -5,6 +5,23 @@ SELECT * FROM users WHERE id = $1 LIMIT 1;
 SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 LIMIT 1);
 
 -- name: CreateUser :one
-INSERT INTO users (id, username, first_name, last_name, created_at) 
-VALUES ($1, $2, $3, $4, $5) 
-RETURNING *;
\ No newline at end of file
+INSERT INTO users (id, username, first_name, last_name, language_code, referred_by, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING *;
+
+-- name: GetUserLanguage :one
+SELECT language FROM users WHERE id = $1 LIMIT 1;
+
+-- name: GetUserLanguages :many
+SELECT id, COALESCE(language, language_code)::varchar AS language 
+FROM users 
+WHERE language IS NOT NULL 
+OR language_code IS NOT NULL;
+
+-- name: UpdateUserLanguage :exec
+UPDATE users 
+SET language = $1 
+WHERE id = $2;
+
+-- name: CountReferrals :one
+SELECT COUNT(*) FROM users WHERE referred_by = sqlc.arg(user_id)::bigint;
\ No newline at end of file
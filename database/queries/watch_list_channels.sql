@@ -0,0 +1,22 @@
+-- name: CreateWatchListChannel :one
+INSERT INTO watch_list_channels (chat_id, channel_id, channel_title, created_at) 
+VALUES ($1, $2, $3, $4) 
+ON CONFLICT (chat_id, channel_id) DO UPDATE SET 
+  channel_title = excluded.channel_title
+RETURNING *;
+
+-- name: DeleteWatchListChannel :exec
+DELETE FROM watch_list_channels 
+WHERE chat_id = $1 
+AND channel_id = $2;
+
+-- name: CountWatchListChannels :one
+SELECT COUNT(*) FROM watch_list_channels WHERE chat_id = $1;
+
+-- name: GetWatchListChannelsByChat :many
+SELECT * FROM watch_list_channels 
+WHERE chat_id = $1
+ORDER BY channel_title ASC;
+
+-- name: GetWatchListChannels :many
+SELECT chat_id, channel_id FROM watch_list_channels;
\ No newline at end of file
@@ -1,13 +1,23 @@
--- name: CreateWatchList :one
+-- name: CreateWatchList :execrows
 INSERT INTO watch_lists (chat_id, product_id, created_at) 
 VALUES ($1, $2, $3) 
-RETURNING *;
+ON CONFLICT (chat_id, product_id) DO NOTHING;
+
+-- name: CreateWatchLists :exec
+INSERT INTO watch_lists (chat_id, product_id, created_at)
+SELECT sqlc.arg(chat_id)::bigint, unnest(sqlc.arg(product_ids)::int[]), sqlc.arg(created_at)::timestamp
+ON CONFLICT (chat_id, product_id) DO NOTHING;
 
 -- name: DeleteWatchList :exec
 DELETE FROM watch_lists 
 WHERE chat_id = $1 
 AND product_id = $2;
 
+-- name: DeleteWatchLists :exec
+DELETE FROM watch_lists 
+WHERE chat_id = sqlc.arg(chat_id) 
+AND product_id = ANY(sqlc.arg(product_ids)::int[]);
+
 -- name: IsWatchListExists :one
 SELECT EXISTS(
 SELECT 1 FROM watch_lists 
@@ -16,8 +26,10 @@ LIMIT 1);
 
 -- name: GetWatchList :many
 SELECT 
+  p.id AS product_id,
   p.name AS product_name, 
-  p.label AS product_label
+  p.label AS product_label,
+  wl.created_at
 FROM watch_lists wl
 JOIN products p ON wl.product_id = p.id
 WHERE wl.chat_id = $1
@@ -28,6 +40,8 @@ SELECT
   p.id AS product_id,
   p.label AS product_label,
   p.eol_url AS product_eol_url,
+  p.api_url AS product_api_url,
+  wl.running_version,
   json_agg(
     json_build_object(
       'release_label', pv.release_label,
@@ -46,7 +60,7 @@ LEFT JOIN LATERAL (
   LIMIT 1
 ) pv ON true
 WHERE wl.chat_id = $1
-GROUP BY p.id
+GROUP BY p.id, wl.running_version
 ORDER BY p.name ASC NULLS LAST;
 
 -- name: GetWatchListsGroupedByChat :many
@@ -54,4 +68,50 @@ SELECT
   chat_id,
   json_agg(DISTINCT product_id) AS product_ids
 FROM watch_lists
-GROUP BY chat_id;
\ No newline at end of file
+GROUP BY chat_id;
+
+-- name: GetRunningVersions :many
+SELECT
+  wl.chat_id,
+  wl.product_id,
+  p.api_url AS product_api_url,
+  wl.running_version::text AS running_version
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.running_version IS NOT NULL;
+
+-- name: SetRunningVersion :execrows
+UPDATE watch_lists
+SET running_version = sqlc.narg(running_version)
+WHERE chat_id = sqlc.arg(chat_id)
+AND product_id = sqlc.arg(product_id);
+
+-- name: GetWatchedProductByNameOrLabel :one
+SELECT p.id, p.name, p.label
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.chat_id = sqlc.arg(chat_id)
+AND (p.name = lower(sqlc.arg(keyword)::text) OR lower(p.label) = lower(sqlc.arg(keyword)::text))
+ORDER BY p.name = lower(sqlc.arg(keyword)::text) DESC
+LIMIT 1;
+
+-- name: SearchWatchedProducts :many
+SELECT p.id, p.name, p.label
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.chat_id = sqlc.arg(chat_id)
+AND (
+  p.label ILIKE '%' || sqlc.arg(keyword)::text || '%' 
+  OR p.name ILIKE '%' || sqlc.arg(keyword)::text || '%' 
+  OR p.label % sqlc.arg(keyword)::text
+  OR p.name % sqlc.arg(keyword)::text
+  OR sqlc.arg(keyword)::text <% p.label
+  OR sqlc.arg(keyword)::text <% p.name
+)
+ORDER BY GREATEST(
+  similarity(p.label, sqlc.arg(keyword)::text),
+  similarity(p.name, sqlc.arg(keyword)::text),
+  word_similarity(sqlc.arg(keyword)::text, p.label),
+  word_similarity(sqlc.arg(keyword)::text, p.name)
+) DESC, p.name ASC
+LIMIT sqlc.arg(page_size)::int;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (chat_id, kind, url, secret, created_at) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks 
+WHERE id = $1 
+AND chat_id = $2;
+
+-- name: CountWebhooks :one
+SELECT COUNT(*) FROM webhooks WHERE chat_id = $1;
+
+-- name: IsWebhookExists :one
+SELECT EXISTS(
+SELECT 1 FROM webhooks 
+WHERE chat_id = $1 AND url = $2
+LIMIT 1);
+
+-- name: GetWebhooksByChat :many
+SELECT * FROM webhooks 
+WHERE chat_id = $1
+ORDER BY id ASC;
+
+-- name: GetWebhooks :many
+SELECT * FROM webhooks;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, event, payload, attempt, status_code, error, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteWebhookDeliveriesBefore :exec
+DELETE FROM webhook_deliveries WHERE created_at < $1;
\ No newline at end of file
@@ -11,18 +11,31 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countReferrals = `-- name: CountReferrals :one
+SELECT COUNT(*) FROM users WHERE referred_by = $1::bigint
+`
+
+func (q *Queries) CountReferrals(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countReferrals, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, username, first_name, last_name, created_at) 
-VALUES ($1, $2, $3, $4, $5) 
-RETURNING id, username, first_name, last_name, created_at
+INSERT INTO users (id, username, first_name, last_name, language_code, referred_by, created_at) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, username, first_name, last_name, created_at, language_code, language, referred_by
 `
 
 type CreateUserParams struct {
-	ID        int64
-	Username  *string
-	FirstName *string
-	LastName  *string
-	CreatedAt pgtype.Timestamp
+	ID           int64
+	Username     *string
+	FirstName    *string
+	LastName     *string
+	LanguageCode *string
+	ReferredBy   *int64
+	CreatedAt    pgtype.Timestamp
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg *CreateUserParams) (*User, error) {
@@ -31,6 +44,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg *CreateUserParams) (*User,
 		arg.Username,
 		arg.FirstName,
 		arg.LastName,
+		arg.LanguageCode,
+		arg.ReferredBy,
 		arg.CreatedAt,
 	)
 	var i User
@@ -40,12 +55,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg *CreateUserParams) (*User,
 		&i.FirstName,
 		&i.LastName,
 		&i.CreatedAt,
+		&i.LanguageCode,
+		&i.Language,
+		&i.ReferredBy,
 	)
 	return &i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, first_name, last_name, created_at FROM users WHERE id = $1 LIMIT 1
+SELECT id, username, first_name, last_name, created_at, language_code, language, referred_by FROM users WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id int64) (*User, error) {
@@ -57,10 +75,56 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (*User, error) {
 		&i.FirstName,
 		&i.LastName,
 		&i.CreatedAt,
+		&i.LanguageCode,
+		&i.Language,
+		&i.ReferredBy,
 	)
 	return &i, err
 }
 
+const getUserLanguage = `-- name: GetUserLanguage :one
+SELECT language FROM users WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserLanguage(ctx context.Context, id int64) (*string, error) {
+	row := q.db.QueryRow(ctx, getUserLanguage, id)
+	var language *string
+	err := row.Scan(&language)
+	return language, err
+}
+
+const getUserLanguages = `-- name: GetUserLanguages :many
+SELECT id, COALESCE(language, language_code)::varchar AS language 
+FROM users 
+WHERE language IS NOT NULL 
+OR language_code IS NOT NULL
+`
+
+type GetUserLanguagesRow struct {
+	ID       int64
+	Language string
+}
+
+func (q *Queries) GetUserLanguages(ctx context.Context) ([]*GetUserLanguagesRow, error) {
+	rows, err := q.db.Query(ctx, getUserLanguages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetUserLanguagesRow{}
+	for rows.Next() {
+		var i GetUserLanguagesRow
+		if err := rows.Scan(&i.ID, &i.Language); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const isUserExists = `-- name: IsUserExists :one
 SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 LIMIT 1)
 `
@@ -71,3 +135,19 @@ func (q *Queries) IsUserExists(ctx context.Context, id int64) (bool, error) {
 	err := row.Scan(&exists)
 	return exists, err
 }
+
+const updateUserLanguage = `-- name: UpdateUserLanguage :exec
+UPDATE users 
+SET language = $1 
+WHERE id = $2
+`
+
+type UpdateUserLanguageParams struct {
+	Language *string
+	ID       int64
+}
+
+func (q *Queries) UpdateUserLanguage(ctx context.Context, arg *UpdateUserLanguageParams) error {
+	_, err := q.db.Exec(ctx, updateUserLanguage, arg.Language, arg.ID)
+	return err
+}
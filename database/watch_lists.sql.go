@@ -11,10 +11,10 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createWatchList = `-- name: CreateWatchList :one
+const createWatchList = `-- name: CreateWatchList :execrows
 INSERT INTO watch_lists (chat_id, product_id, created_at) 
 VALUES ($1, $2, $3) 
-RETURNING id, chat_id, product_id, created_at
+ON CONFLICT (chat_id, product_id) DO NOTHING
 `
 
 type CreateWatchListParams struct {
@@ -23,16 +23,29 @@ type CreateWatchListParams struct {
 	CreatedAt pgtype.Timestamp
 }
 
-func (q *Queries) CreateWatchList(ctx context.Context, arg *CreateWatchListParams) (*WatchList, error) {
-	row := q.db.QueryRow(ctx, createWatchList, arg.ChatID, arg.ProductID, arg.CreatedAt)
-	var i WatchList
-	err := row.Scan(
-		&i.ID,
-		&i.ChatID,
-		&i.ProductID,
-		&i.CreatedAt,
-	)
-	return &i, err
+func (q *Queries) CreateWatchList(ctx context.Context, arg *CreateWatchListParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createWatchList, arg.ChatID, arg.ProductID, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createWatchLists = `-- name: CreateWatchLists :exec
+INSERT INTO watch_lists (chat_id, product_id, created_at)
+SELECT $1::bigint, unnest($2::int[]), $3::timestamp
+ON CONFLICT (chat_id, product_id) DO NOTHING
+`
+
+type CreateWatchListsParams struct {
+	ChatID     int64
+	ProductIds []int32
+	CreatedAt  pgtype.Timestamp
+}
+
+func (q *Queries) CreateWatchLists(ctx context.Context, arg *CreateWatchListsParams) error {
+	_, err := q.db.Exec(ctx, createWatchLists, arg.ChatID, arg.ProductIds, arg.CreatedAt)
+	return err
 }
 
 const deleteWatchList = `-- name: DeleteWatchList :exec
@@ -51,10 +64,71 @@ func (q *Queries) DeleteWatchList(ctx context.Context, arg *DeleteWatchListParam
 	return err
 }
 
+const deleteWatchLists = `-- name: DeleteWatchLists :exec
+DELETE FROM watch_lists 
+WHERE chat_id = $1 
+AND product_id = ANY($2::int[])
+`
+
+type DeleteWatchListsParams struct {
+	ChatID     int64
+	ProductIds []int32
+}
+
+func (q *Queries) DeleteWatchLists(ctx context.Context, arg *DeleteWatchListsParams) error {
+	_, err := q.db.Exec(ctx, deleteWatchLists, arg.ChatID, arg.ProductIds)
+	return err
+}
+
+const getRunningVersions = `-- name: GetRunningVersions :many
+SELECT
+  wl.chat_id,
+  wl.product_id,
+  p.api_url AS product_api_url,
+  wl.running_version::text AS running_version
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.running_version IS NOT NULL
+`
+
+type GetRunningVersionsRow struct {
+	ChatID         int64
+	ProductID      int32
+	ProductApiUrl  string
+	RunningVersion string
+}
+
+func (q *Queries) GetRunningVersions(ctx context.Context) ([]*GetRunningVersionsRow, error) {
+	rows, err := q.db.Query(ctx, getRunningVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetRunningVersionsRow{}
+	for rows.Next() {
+		var i GetRunningVersionsRow
+		if err := rows.Scan(
+			&i.ChatID,
+			&i.ProductID,
+			&i.ProductApiUrl,
+			&i.RunningVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWatchList = `-- name: GetWatchList :many
 SELECT 
+  p.id AS product_id,
   p.name AS product_name, 
-  p.label AS product_label
+  p.label AS product_label,
+  wl.created_at
 FROM watch_lists wl
 JOIN products p ON wl.product_id = p.id
 WHERE wl.chat_id = $1
@@ -62,8 +136,10 @@ ORDER BY p.name ASC NULLS LAST
 `
 
 type GetWatchListRow struct {
+	ProductID    int32
 	ProductName  string
 	ProductLabel string
+	CreatedAt    pgtype.Timestamp
 }
 
 func (q *Queries) GetWatchList(ctx context.Context, chatID int64) ([]*GetWatchListRow, error) {
@@ -75,7 +151,12 @@ func (q *Queries) GetWatchList(ctx context.Context, chatID int64) ([]*GetWatchLi
 	items := []*GetWatchListRow{}
 	for rows.Next() {
 		var i GetWatchListRow
-		if err := rows.Scan(&i.ProductName, &i.ProductLabel); err != nil {
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.ProductName,
+			&i.ProductLabel,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, &i)
@@ -124,6 +205,8 @@ SELECT
   p.id AS product_id,
   p.label AS product_label,
   p.eol_url AS product_eol_url,
+  p.api_url AS product_api_url,
+  wl.running_version,
   json_agg(
     json_build_object(
       'release_label', pv.release_label,
@@ -142,7 +225,7 @@ LEFT JOIN LATERAL (
   LIMIT 1
 ) pv ON true
 WHERE wl.chat_id = $1
-GROUP BY p.id
+GROUP BY p.id, wl.running_version
 ORDER BY p.name ASC NULLS LAST
 `
 
@@ -150,6 +233,8 @@ type GetWatchListsWithProductVersionsRow struct {
 	ProductID       int32
 	ProductLabel    string
 	ProductEolUrl   string
+	ProductApiUrl   string
+	RunningVersion  *string
 	ProductVersions []byte
 }
 
@@ -166,6 +251,8 @@ func (q *Queries) GetWatchListsWithProductVersions(ctx context.Context, chatID i
 			&i.ProductID,
 			&i.ProductLabel,
 			&i.ProductEolUrl,
+			&i.ProductApiUrl,
+			&i.RunningVersion,
 			&i.ProductVersions,
 		); err != nil {
 			return nil, err
@@ -178,6 +265,34 @@ func (q *Queries) GetWatchListsWithProductVersions(ctx context.Context, chatID i
 	return items, nil
 }
 
+const getWatchedProductByNameOrLabel = `-- name: GetWatchedProductByNameOrLabel :one
+SELECT p.id, p.name, p.label
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.chat_id = $1
+AND (p.name = lower($2::text) OR lower(p.label) = lower($2::text))
+ORDER BY p.name = lower($2::text) DESC
+LIMIT 1
+`
+
+type GetWatchedProductByNameOrLabelParams struct {
+	ChatID  int64
+	Keyword string
+}
+
+type GetWatchedProductByNameOrLabelRow struct {
+	ID    int32
+	Name  string
+	Label string
+}
+
+func (q *Queries) GetWatchedProductByNameOrLabel(ctx context.Context, arg *GetWatchedProductByNameOrLabelParams) (*GetWatchedProductByNameOrLabelRow, error) {
+	row := q.db.QueryRow(ctx, getWatchedProductByNameOrLabel, arg.ChatID, arg.Keyword)
+	var i GetWatchedProductByNameOrLabelRow
+	err := row.Scan(&i.ID, &i.Name, &i.Label)
+	return &i, err
+}
+
 const isWatchListExists = `-- name: IsWatchListExists :one
 SELECT EXISTS(
 SELECT 1 FROM watch_lists 
@@ -196,3 +311,78 @@ func (q *Queries) IsWatchListExists(ctx context.Context, arg *IsWatchListExistsP
 	err := row.Scan(&exists)
 	return exists, err
 }
+
+const searchWatchedProducts = `-- name: SearchWatchedProducts :many
+SELECT p.id, p.name, p.label
+FROM watch_lists wl
+JOIN products p ON wl.product_id = p.id
+WHERE wl.chat_id = $1
+AND (
+  p.label ILIKE '%' || $2::text || '%' 
+  OR p.name ILIKE '%' || $2::text || '%' 
+  OR p.label % $2::text
+  OR p.name % $2::text
+  OR $2::text <% p.label
+  OR $2::text <% p.name
+)
+ORDER BY GREATEST(
+  similarity(p.label, $2::text),
+  similarity(p.name, $2::text),
+  word_similarity($2::text, p.label),
+  word_similarity($2::text, p.name)
+) DESC, p.name ASC
+LIMIT $3::int
+`
+
+type SearchWatchedProductsParams struct {
+	ChatID   int64
+	Keyword  string
+	PageSize int32
+}
+
+type SearchWatchedProductsRow struct {
+	ID    int32
+	Name  string
+	Label string
+}
+
+func (q *Queries) SearchWatchedProducts(ctx context.Context, arg *SearchWatchedProductsParams) ([]*SearchWatchedProductsRow, error) {
+	rows, err := q.db.Query(ctx, searchWatchedProducts, arg.ChatID, arg.Keyword, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*SearchWatchedProductsRow{}
+	for rows.Next() {
+		var i SearchWatchedProductsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Label); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setRunningVersion = `-- name: SetRunningVersion :execrows
+UPDATE watch_lists
+SET running_version = $1
+WHERE chat_id = $2
+AND product_id = $3
+`
+
+type SetRunningVersionParams struct {
+	RunningVersion *string
+	ChatID         int64
+	ProductID      int32
+}
+
+func (q *Queries) SetRunningVersion(ctx context.Context, arg *SetRunningVersionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setRunningVersion, arg.RunningVersion, arg.ChatID, arg.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,121 @@
+// Package chatlock processes the updates of a chat one at a time, e.g. two quick taps on
+// inline buttons arriving as concurrent webhook requests. The in-process lock covers a single
+// instance, the Postgres backend also takes an advisory lock for several instances.
+package chatlock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+type keyLock struct {
+	ch   chan struct{}
+	refs int // Holders and waiters, the lock is dropped at 0
+}
+
+var (
+	mu    sync.Mutex
+	locks = map[int64]*keyLock{}
+
+	// An advisory lock holds a connection, half of the pool is left for the queries
+	remoteSlots     chan struct{}
+	remoteSlotsOnce sync.Once
+)
+
+// Lock waits for the lock of a chat, the returned function releases it
+func Lock(ctx context.Context, key int64) (func(), error) {
+	unlockLocal, err := lockLocal(ctx, key)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if config.Cfg.ChatLockBackend != BackendPostgres {
+		return unlockLocal, nil
+	}
+
+	// The local lock goes first, an instance holds a single connection per chat
+	unlockRemote, err := lockRemote(ctx, key)
+	if err != nil {
+		unlockLocal()
+		return nil, utils.NewError(err)
+	}
+
+	return func() {
+		unlockRemote()
+		unlockLocal()
+	}, nil
+}
+
+func lockLocal(ctx context.Context, key int64) (func(), error) {
+	mu.Lock()
+	l, ok := locks[key]
+	if !ok {
+		l = &keyLock{ch: make(chan struct{}, 1)}
+		locks[key] = l
+	}
+	l.refs++
+	mu.Unlock()
+
+	release := func() {
+		mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(locks, key)
+		}
+		mu.Unlock()
+	}
+
+	select {
+	case l.ch <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-l.ch
+		release()
+	}, nil
+}
+
+// lockRemote takes the advisory lock in a transaction, which releases it when it ends, even if
+// the connection is lost
+func lockRemote(ctx context.Context, key int64) (func(), error) {
+	remoteSlotsOnce.Do(func() {
+		remoteSlots = make(chan struct{}, max(database.Pool.Config().MaxConns/2, 1))
+	})
+
+	select {
+	case remoteSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	tx, err := database.Pool.Begin(ctx)
+	if err != nil {
+		<-remoteSlots
+		return nil, err
+	}
+
+	// Released even if the update timed out
+	release := func() {
+		_ = tx.Rollback(context.WithoutCancel(ctx))
+		<-remoteSlots
+	}
+
+	err = database.Sqlc.WithTx(tx).LockChat(ctx, key)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
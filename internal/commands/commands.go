@@ -0,0 +1,95 @@
+// Package commands is the registry of bot commands, listed by /help and registered as
+// the command menu of every language and chat type
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+)
+
+// Scope is the kind of chat a command is offered in
+type Scope int
+
+const (
+	ScopePrivate Scope = 1 << iota
+	ScopeGroup
+
+	ScopeAll = ScopePrivate | ScopeGroup
+)
+
+type Command struct {
+	// Name without the leading slash
+	Name  string
+	Scope Scope
+}
+
+// Registry lists the commands in the order they are shown. The description of a command
+// is the command_<name> message template.
+var Registry = []Command{
+	{Name: "start", Scope: ScopePrivate},
+	{Name: "help", Scope: ScopeAll},
+	{Name: "watch", Scope: ScopeAll},
+	{Name: "unwatch", Scope: ScopeAll},
+	{Name: "import", Scope: ScopeAll},
+	{Name: "export", Scope: ScopeAll},
+	{Name: "collection", Scope: ScopeAll},
+	{Name: "share", Scope: ScopePrivate},
+	{Name: "running", Scope: ScopeAll},
+	{Name: "version", Scope: ScopeAll},
+	{Name: "history", Scope: ScopeAll},
+	{Name: "channel", Scope: ScopeAll},
+	{Name: "webhook", Scope: ScopeAll},
+	{Name: "email", Scope: ScopeAll},
+	// The language belongs to the user, not to the group
+	{Name: "language", Scope: ScopePrivate},
+	{Name: "cancel", Scope: ScopeAll},
+}
+
+// Describe returns the commands of a scope with their description in the locale of the context
+func Describe(ctx context.Context, scope Scope) []service.Command {
+	var commands []service.Command
+	for _, command := range Registry {
+		if command.Scope&scope == 0 {
+			continue
+		}
+		commands = append(commands, service.Command{
+			Command:     "/" + command.Name,
+			Description: message.Text(ctx, "command_"+command.Name, nil),
+		})
+	}
+	return commands
+}
+
+// Register sets the command menu of private and group chats in every locale. Clients in
+// a language without translation get the menu of the default locale.
+func Register(ctx context.Context) error {
+	scopes := []struct {
+		scope     Scope
+		scopeType string
+	}{
+		{ScopePrivate, service.CommandScopeAllPrivateChats},
+		{ScopeGroup, service.CommandScopeAllGroupChats},
+	}
+
+	for _, s := range scopes {
+		for _, locale := range message.Locales() {
+			params := service.SetMyCommandsParams{
+				Commands: Describe(message.WithLocale(ctx, locale), s.scope),
+				Scope:    &service.CommandScope{Type: s.scopeType},
+			}
+			if locale != message.DefaultLocale {
+				params.LanguageCode = locale
+			}
+
+			err := service.SetMyCommands(ctx, &params)
+			if err != nil {
+				return fmt.Errorf("%s (%s): %v", s.scopeType, locale, err)
+			}
+		}
+	}
+
+	return nil
+}
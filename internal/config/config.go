@@ -3,18 +3,34 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	AppEnv             string
-	AppHost            string
-	AppPort            string
-	TelegramBotToken   string
-	DatabaseURL        string
-	WebhookURL         string
-	WebhookSecretToken string
+	AppEnv              string
+	AppHost             string
+	AppPort             string
+	TelegramBotToken    string
+	TelegramBotUsername string
+	DatabaseURL         string
+	WebhookURL          string
+	WebhookSecretToken  string
+	SmtpHost            string
+	SmtpPort            string
+	SmtpUsername        string
+	SmtpPassword        string
+	SmtpFrom            string
+	TemplatesDir        string
+	// Updates are processed by this many workers in the background (8 by default), 0 processes
+	// them in the webhook request
+	QueueWorkers int
+	// "memory" or "postgres", which keeps the queued updates across restarts
+	QueueBackend string
+	// "memory" locks chats within the process, "postgres" also across instances
+	ChatLockBackend string
 }
 
 var Cfg *Config
@@ -23,13 +39,23 @@ func LoadConfig() error {
 	godotenv.Load()
 
 	Cfg = &Config{
-		AppEnv:             os.Getenv("APP_ENV"),
-		AppHost:            os.Getenv("APP_HOST"),
-		AppPort:            os.Getenv("APP_PORT"),
-		TelegramBotToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
-		DatabaseURL:        os.Getenv("DATABASE_URL"),
-		WebhookURL:         os.Getenv("WEBHOOK_URL"),
-		WebhookSecretToken: os.Getenv("WEBHOOK_SECRET_TOKEN"),
+		AppEnv:              os.Getenv("APP_ENV"),
+		AppHost:             os.Getenv("APP_HOST"),
+		AppPort:             os.Getenv("APP_PORT"),
+		TelegramBotToken:    os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramBotUsername: strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_USERNAME"), "@"),
+		DatabaseURL:         os.Getenv("DATABASE_URL"),
+		WebhookURL:          os.Getenv("WEBHOOK_URL"),
+		WebhookSecretToken:  os.Getenv("WEBHOOK_SECRET_TOKEN"),
+		SmtpHost:            os.Getenv("SMTP_HOST"),
+		SmtpPort:            os.Getenv("SMTP_PORT"),
+		SmtpUsername:        os.Getenv("SMTP_USERNAME"),
+		SmtpPassword:        os.Getenv("SMTP_PASSWORD"),
+		SmtpFrom:            os.Getenv("SMTP_FROM"),
+		TemplatesDir:        os.Getenv("TEMPLATES_DIR"),
+		QueueWorkers:        8,
+		QueueBackend:        os.Getenv("QUEUE_BACKEND"),
+		ChatLockBackend:     os.Getenv("CHAT_LOCK_BACKEND"),
 	}
 
 	// Validate
@@ -57,6 +83,34 @@ func LoadConfig() error {
 	if Cfg.WebhookSecretToken == "" {
 		return fmt.Errorf("missing WEBHOOK_SECRET_TOKEN")
 	}
+	if workers := os.Getenv("QUEUE_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid QUEUE_WORKERS: %s", workers)
+		}
+		Cfg.QueueWorkers = n
+	}
+	if Cfg.QueueBackend == "" {
+		Cfg.QueueBackend = "memory"
+	}
+	if Cfg.QueueBackend != "memory" && Cfg.QueueBackend != "postgres" {
+		return fmt.Errorf("invalid QUEUE_BACKEND: %s", Cfg.QueueBackend)
+	}
+	if Cfg.ChatLockBackend == "" {
+		Cfg.ChatLockBackend = "memory"
+	}
+	if Cfg.ChatLockBackend != "memory" && Cfg.ChatLockBackend != "postgres" {
+		return fmt.Errorf("invalid CHAT_LOCK_BACKEND: %s", Cfg.ChatLockBackend)
+	}
+	// SMTP is optional, email notifications are disabled without it
+	if Cfg.SmtpHost != "" {
+		if Cfg.SmtpPort == "" {
+			Cfg.SmtpPort = "587"
+		}
+		if Cfg.SmtpFrom == "" {
+			return fmt.Errorf("missing SMTP_FROM")
+		}
+	}
 
 	return nil
 }
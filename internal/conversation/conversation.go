@@ -0,0 +1,160 @@
+// Package conversation runs multi-step flows. A flow declares its steps, each step handles an
+// update with the typed state of the session and tells where to go next. The state is kept
+// as JSON in chats.data and expires after the timeout of the step waiting for the next update.
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+type Step[S any] struct {
+	// How long the session waits in this step, repository.DefaultChatTimeout if zero
+	Timeout time.Duration
+	Handle  func(ctx context.Context, req types.TelegramUpdate, state *S) (Transition, error)
+}
+
+// Transition is the reply of a step and what happens to the session
+type Transition struct {
+	Response *types.TelegramResponse
+	next     int16 // 0 stays in the current step
+	end      bool
+}
+
+// Next moves the session to another step
+func Next(step int16, resp *types.TelegramResponse) Transition {
+	return Transition{Response: resp, next: step}
+}
+
+// Stay keeps the session in the current step, e.g. to ask again. The timeout starts over.
+func Stay(resp *types.TelegramResponse) Transition {
+	return Transition{Response: resp}
+}
+
+// End deletes the session
+func End(resp *types.TelegramResponse) Transition {
+	return Transition{Response: resp, end: true}
+}
+
+// Flow is a conversation started by a command, S is the state carried between steps
+type Flow[S any] struct {
+	Command string
+	// Step run when there is no session
+	First int16
+	Steps map[int16]Step[S]
+}
+
+// Run hands the update to the step of the session, or to the first step without one
+func (f *Flow[S]) Run(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return f.run(ctx, req, 0)
+}
+
+// Restart drops the session, if any, and runs the first step
+func (f *Flow[S]) Restart(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return f.run(ctx, req, f.First)
+}
+
+// RestartAt drops the session, if any, and runs the given step with an empty state, e.g. for
+// a deep link skipping the first step
+func (f *Flow[S]) RestartAt(ctx context.Context, req types.TelegramUpdate, step int16) (*types.TelegramResponse, error) {
+	return f.run(ctx, req, step)
+}
+
+// run resumes the session when start is 0
+func (f *Flow[S]) run(ctx context.Context, req types.TelegramUpdate, start int16) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	var state S
+	stepId := start
+	if start == 0 {
+		stepId = f.First
+
+		// Get chat
+		chat, err := repository.TelegramGetChat(ctx, chatId, userId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewError(err)
+		}
+		if chat != nil && chat.Command == f.Command {
+			stepId = chat.Step
+			if len(chat.Data) > 0 {
+				if err := sonic.Unmarshal(chat.Data, &state); err != nil {
+					return nil, utils.NewError(err)
+				}
+			}
+		}
+	}
+
+	step, ok := f.Steps[stepId]
+	if !ok {
+		// Delete chat
+		err := repository.TelegramDeleteChat(ctx, chatId, userId)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "unhandled_step", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	transition, err := step.Handle(ctx, req, &state)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	if transition.end {
+		// Delete chat
+		err := repository.TelegramDeleteChat(ctx, chatId, userId)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return transition.Response, nil
+	}
+
+	if transition.next != 0 {
+		stepId = transition.next
+	}
+	next, ok := f.Steps[stepId]
+	if !ok {
+		return nil, utils.NewError(fmt.Errorf("%s: unknown step %d", f.Command, stepId))
+	}
+
+	data, err := sonic.Marshal(state)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	// Set step
+	_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
+		ID:      chatId,
+		UserID:  userId,
+		Command: f.Command,
+		Step:    stepId,
+		Data:    data,
+		Timeout: next.Timeout,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	return transition.Response, nil
+}
@@ -0,0 +1,123 @@
+// Package deeplink builds and reads the payloads of t.me/<bot>?start=<payload> links.
+// A payload is <kind>_<value>, optionally followed by __<referrer user id> (e.g. watch_nginx__42).
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+)
+
+const (
+	// Pre-fills the watch flow with a product name
+	KindWatch = "watch"
+	// Subscribes to a collection
+	KindCollection = "col"
+	// Only tells who shared the link
+	KindReferral = "ref"
+)
+
+// Telegram accepts up to 64 characters of A-Z, a-z, 0-9, _ and -
+const maxLength = 64
+
+const referrerSeparator = "__"
+
+var ErrInvalid = errors.New("invalid deep link payload")
+
+type Payload struct {
+	Kind  string
+	Value string
+	// User who shared the link, 0 if unknown
+	Referrer int64
+}
+
+// Encode returns the payload as passed to /start
+func (p *Payload) Encode() (string, error) {
+	if p.Kind == KindReferral {
+		return KindReferral + "_" + strconv.FormatInt(p.Referrer, 10), nil
+	}
+
+	if p.Value == "" || strings.Contains(p.Value, referrerSeparator) {
+		return "", ErrInvalid
+	}
+	s := p.Kind + "_" + p.Value
+	if p.Referrer != 0 {
+		s += referrerSeparator + strconv.FormatInt(p.Referrer, 10)
+	}
+	if !isValid(s) {
+		return "", ErrInvalid
+	}
+	return s, nil
+}
+
+// Parse reads the payload of "/start <payload>"
+func Parse(s string) (*Payload, error) {
+	if s == "" || !isValid(s) {
+		return nil, ErrInvalid
+	}
+
+	kind, value, ok := strings.Cut(s, "_")
+	if !ok || value == "" {
+		return nil, ErrInvalid
+	}
+	payload := Payload{
+		Kind: kind,
+	}
+
+	switch kind {
+	case KindReferral:
+		referrer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || referrer <= 0 {
+			return nil, ErrInvalid
+		}
+		payload.Referrer = referrer
+
+	case KindWatch, KindCollection:
+		value, referrer, ok := strings.Cut(value, referrerSeparator)
+		if value == "" {
+			return nil, ErrInvalid
+		}
+		payload.Value = value
+		if ok {
+			// A broken referrer doesn't break the link
+			if id, err := strconv.ParseInt(referrer, 10, 64); err == nil && id > 0 {
+				payload.Referrer = id
+			}
+		}
+
+	default:
+		return nil, ErrInvalid
+	}
+
+	return &payload, nil
+}
+
+// Link returns the link opening the bot with the payload, empty if the bot username is not configured
+func Link(payload string) string {
+	if config.Cfg.TelegramBotUsername == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", config.Cfg.TelegramBotUsername, payload)
+}
+
+// Command returns the command doing what the link does
+func Command(payload string) string {
+	return "/start " + payload
+}
+
+func isValid(s string) bool {
+	if len(s) > maxLength {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
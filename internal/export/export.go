@@ -0,0 +1,144 @@
+// Package export writes watch lists as JSON or CSV files and reads them back
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// Version of the JSON format, increased on breaking changes
+const Version = 1
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var ErrUnsupported = errors.New("unsupported export")
+
+var csvHeader = []string{"product", "label", "watched_since"}
+
+type WatchList struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Language chosen with /language, CSV files don't keep it
+	Language string    `json:"language,omitempty"`
+	Products []Product `json:"products"`
+}
+
+type Product struct {
+	// Name on endoflife.date, used to find the product on import
+	Name         string    `json:"name"`
+	Label        string    `json:"label"`
+	WatchedSince time.Time `json:"watched_since"`
+}
+
+// Format returns the format of an exported file, judging by its name
+func Format(fileName string) (string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// FileName returns the name of the exported file
+func FileName(format string) string {
+	return "watch-list." + format
+}
+
+// Marshal writes the watch list in the given format
+func Marshal(watchList *WatchList, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return sonic.ConfigStd.MarshalIndent(watchList, "", "  ")
+	case FormatCSV:
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		for _, product := range watchList.Products {
+			err := writer.Write([]string{
+				product.Name,
+				product.Label,
+				product.WatchedSince.Format(time.DateOnly),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		writer.Flush()
+		return buf.Bytes(), writer.Error()
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// Parse reads a file written by Marshal. Only the product names are required,
+// so a hand-written CSV with a "product" column works too.
+func Parse(fileName string, content []byte) (*WatchList, error) {
+	format, err := Format(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	watchList := WatchList{}
+	switch format {
+	case FormatJSON:
+		if err := sonic.Unmarshal(content, &watchList); err != nil {
+			return nil, err
+		}
+		if watchList.Version == 0 || watchList.Version > Version {
+			return nil, fmt.Errorf("unsupported version %d", watchList.Version)
+		}
+
+	case FormatCSV:
+		records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, errors.New("empty file")
+		}
+
+		// Columns may be in any order
+		nameColumn, labelColumn := -1, -1
+		for i, column := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "product", "name":
+				nameColumn = i
+			case "label":
+				labelColumn = i
+			}
+		}
+		if nameColumn < 0 {
+			return nil, errors.New("missing product column")
+		}
+
+		for _, record := range records[1:] {
+			product := Product{
+				Name: record[nameColumn],
+			}
+			if labelColumn >= 0 {
+				product.Label = record[labelColumn]
+			}
+			watchList.Products = append(watchList.Products, product)
+		}
+	}
+
+	for i := range watchList.Products {
+		watchList.Products[i].Name = strings.ToLower(strings.TrimSpace(watchList.Products[i].Name))
+	}
+	return &watchList, nil
+}
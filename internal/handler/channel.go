@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxLinkedChannels = 5
+
+var channelFlow = &conversation.Flow[struct{}]{
+	Command: "channel",
+	First:   1,
+	Steps: map[int16]conversation.Step[struct{}]{
+		1: {Handle: channelStepList},
+		2: {Handle: channelStepLink},
+	},
+}
+
+func Channel(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return channelFlow.Run(ctx, req)
+}
+
+// channelStepList lists the linked channels
+func channelStepList(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	channels, err := database.Sqlc.GetWatchListChannelsByChat(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "channel_list", channels)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, len(channels)+1) // +1 for cancel button
+	for _, channel := range channels {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_unlink_channel", channel.ChannelTitle),
+				CallbackData: fmt.Sprintf("unlink_%d", channel.ChannelID),
+			},
+		})
+	}
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text:         message.Text(ctx, "button_cancel", nil),
+			CallbackData: "cancel",
+		},
+	})
+
+	resp, err := buttonsPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(2, resp), nil
+}
+
+// channelStepLink links the sent channel, or unlinks the chosen one
+func channelStepLink(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
+
+	if strings.HasPrefix(req.CallbackQuery.Data, "unlink_") {
+		channelId, err := strconv.ParseInt(strings.TrimPrefix(req.CallbackQuery.Data, "unlink_"), 10, 64)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Delete linked channel
+		err = database.Sqlc.DeleteWatchListChannel(ctx, &database.DeleteWatchListChannelParams{
+			ChatID:    chatId,
+			ChannelID: channelId,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Answer callback query
+		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "channel_unlinked", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// It must be text message
+	if req.Message.Text == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	count, err := database.Sqlc.CountWatchListChannels(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	if count >= maxLinkedChannels {
+		text, err := message.Render(ctx, "channel_limit", maxLinkedChannels)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	// Verify the channel
+	channel, reason, err := verifyChannel(ctx, req.Message.Text, userId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	if reason != "" {
+		text, err := message.Render(ctx, "channel_invalid", message.Text(ctx, reason, nil))
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	// Link channel
+	_, err = database.Sqlc.CreateWatchListChannel(ctx, &database.CreateWatchListChannelParams{
+		ChatID:       chatId,
+		ChannelID:    channel.Id,
+		ChannelTitle: channel.Title,
+		CreatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "channel_linked", channel.Title)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}), nil
+}
+
+// verifyChannel resolves a channel reference (@username, t.me link or id) and makes sure
+// both the bot and the user are administrators of it.
+// The name of the reason template is returned when the channel can't be linked.
+func verifyChannel(ctx context.Context, ref string, userId int64) (*types.TelegramChat, string, error) {
+	ref = strings.TrimSpace(ref)
+	ref = strings.TrimPrefix(ref, "https://")
+	ref = strings.TrimPrefix(ref, "t.me/")
+
+	var chatRef any
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		chatRef = id
+	} else {
+		chatRef = "@" + strings.TrimPrefix(ref, "@")
+	}
+
+	var telegramErr *service.TelegramError
+
+	channel, err := service.GetChat(ctx, &service.GetChatParams{
+		ChatId: chatRef,
+	})
+	if err != nil {
+		if errors.As(err, &telegramErr) {
+			return nil, "reason_channel_not_found", nil
+		}
+		return nil, "", utils.NewError(err)
+	}
+	if channel.Type != types.TelegramChatTypeChannel {
+		return nil, "reason_not_a_channel", nil
+	}
+
+	// Bot must be able to post in the channel
+	botMember, err := service.GetChatMember(ctx, &service.GetChatMemberParams{
+		ChatId: channel.Id,
+		UserId: service.BotId(),
+	})
+	if err != nil {
+		if errors.As(err, &telegramErr) {
+			return nil, "reason_bot_not_channel_admin", nil
+		}
+		return nil, "", utils.NewError(err)
+	}
+	if botMember.Status != types.TelegramChatMemberStatusAdministrator {
+		return nil, "reason_bot_not_channel_admin", nil
+	}
+
+	// User must manage the channel
+	userMember, err := service.GetChatMember(ctx, &service.GetChatMemberParams{
+		ChatId: channel.Id,
+		UserId: userId,
+	})
+	if err != nil {
+		if errors.As(err, &telegramErr) {
+			return nil, "reason_user_not_channel_admin", nil
+		}
+		return nil, "", utils.NewError(err)
+	}
+	if userMember.Status != types.TelegramChatMemberStatusCreator &&
+		userMember.Status != types.TelegramChatMemberStatusAdministrator {
+		return nil, "reason_user_not_channel_admin", nil
+	}
+
+	return channel, "", nil
+}
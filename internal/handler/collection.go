@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/deeplink"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Maximum length of a collection name, as stored in collections
+const collectionNameMaxLength = 100
+
+type collectionItem struct {
+	ID               int32
+	Name             string
+	TotalProducts    int64
+	TotalSubscribers int64
+	Link             string
+	Start            string
+}
+
+type collectionAddResult struct {
+	Name         string
+	Added        []string
+	AlreadyAdded []string
+	Ambiguous    []watchBulkAmbiguous
+	NotFound     []string
+	Skipped      int
+	Limit        int
+}
+
+// Collection manages the collections of the user, lists of products others subscribe to
+// with /start col_<id>:
+//
+//	/collection                             list owned and subscribed collections
+//	/collection <id>                        show the products of a collection
+//	/collection new <name>                  create a collection
+//	/collection add <id> <product, ...>     add products, subscribers watch them too
+//	/collection remove <id> <product>       remove a product
+//	/collection delete <id>                 delete a collection
+//	/collection leave <id>                  stop following a collection
+func Collection(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}
+	}
+	render := func(name string, data any) (*types.TelegramResponse, error) {
+		text, err := message.Render(ctx, name, data)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	// Drop the command
+	_, args, _ := strings.Cut(req.Message.Text, " ")
+	action, args, _ := strings.Cut(strings.TrimSpace(args), " ")
+	action = strings.ToLower(action)
+	args = strings.TrimSpace(args)
+
+	if action == "" {
+		owned, err := database.Sqlc.GetCollectionsByOwner(ctx, userId)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		subscribed, err := database.Sqlc.GetCollectionSubscriptionsByChat(ctx, chatId)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		data := struct {
+			Owned      []collectionItem
+			Subscribed []*database.GetCollectionSubscriptionsByChatRow
+		}{
+			Subscribed: subscribed,
+		}
+		for _, collection := range owned {
+			link, start := collectionShare(collection.ID, userId)
+			data.Owned = append(data.Owned, collectionItem{
+				ID:               collection.ID,
+				Name:             collection.Name,
+				TotalProducts:    collection.TotalProducts,
+				TotalSubscribers: collection.TotalSubscribers,
+				Link:             link,
+				Start:            start,
+			})
+		}
+		return render("collection_list", data)
+	}
+
+	// Show a collection, "/collection <id>"
+	if id, ok := parseCollectionId(action); ok {
+		collection, err := getCollection(ctx, id)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if collection == nil {
+			return render("collection_not_found", id)
+		}
+		products, err := database.Sqlc.GetCollectionProducts(ctx, id)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		link, start := collectionShare(collection.ID, collection.OwnerID)
+		data := struct {
+			collectionItem
+			Products []string
+		}{
+			collectionItem: collectionItem{
+				ID:    collection.ID,
+				Name:  collection.Name,
+				Link:  link,
+				Start: start,
+			},
+		}
+		for _, product := range products {
+			data.Products = append(data.Products, product.Label)
+		}
+		return render("collection_detail", data)
+	}
+
+	switch action {
+	case "new":
+		if args == "" || utf8.RuneCountInString(args) > collectionNameMaxLength {
+			return render("collection_usage", nil)
+		}
+
+		collection, err := database.Sqlc.CreateCollection(ctx, &database.CreateCollectionParams{
+			OwnerID:   userId,
+			Name:      args,
+			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		link, start := collectionShare(collection.ID, userId)
+		return render("collection_created", collectionItem{
+			ID:    collection.ID,
+			Name:  collection.Name,
+			Link:  link,
+			Start: start,
+		})
+
+	case "add", "remove", "delete", "leave":
+	default:
+		return render("collection_usage", nil)
+	}
+
+	idArg, args, _ := strings.Cut(args, " ")
+	args = strings.TrimSpace(args)
+	id, ok := parseCollectionId(idArg)
+	if !ok {
+		return render("collection_usage", nil)
+	}
+
+	// Leaving is up to the subscriber
+	if action == "leave" {
+		rows, err := database.Sqlc.DeleteCollectionSubscription(ctx, &database.DeleteCollectionSubscriptionParams{
+			CollectionID: id,
+			ChatID:       chatId,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if rows == 0 {
+			return render("collection_not_subscribed", id)
+		}
+		return render("collection_left", id)
+	}
+
+	// Everything else is up to the owner
+	collection, err := getCollection(ctx, id)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if collection == nil || collection.OwnerID != userId {
+		return render("collection_not_found", id)
+	}
+
+	switch action {
+	case "add":
+		keywords := splitProductNames(args)
+		if len(keywords) == 0 {
+			return render("collection_usage", nil)
+		}
+
+		result := collectionAddResult{
+			Name:  collection.Name,
+			Limit: watchBulkLimit,
+		}
+		if len(keywords) > watchBulkLimit {
+			result.Skipped = len(keywords) - watchBulkLimit
+			keywords = keywords[:watchBulkLimit]
+		}
+
+		products, err := database.Sqlc.GetCollectionProducts(ctx, id)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		productIds := []int32{}
+		for _, keyword := range keywords {
+			match, err := matchProduct(ctx, keyword)
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+			switch {
+			case match == nil:
+				result.NotFound = append(result.NotFound, keyword)
+				continue
+			case len(match.Candidates) > 0:
+				result.Ambiguous = append(result.Ambiguous, watchBulkAmbiguous{
+					Keyword:    keyword,
+					Candidates: match.Candidates,
+				})
+				continue
+			}
+
+			// The same product may be named twice
+			if slices.Contains(productIds, match.ID) {
+				continue
+			}
+			if slices.ContainsFunc(products, func(product *database.GetCollectionProductsRow) bool {
+				return product.ID == match.ID
+			}) {
+				result.AlreadyAdded = append(result.AlreadyAdded, match.Label)
+				continue
+			}
+			productIds = append(productIds, match.ID)
+			result.Added = append(result.Added, match.Label)
+		}
+
+		if len(productIds) > 0 {
+			now := pgtype.Timestamp{Time: time.Now(), Valid: true}
+			err = database.Sqlc.AddCollectionProducts(ctx, &database.AddCollectionProductsParams{
+				CollectionID: id,
+				ProductIds:   productIds,
+				CreatedAt:    now,
+			})
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+
+			// Subscribers watch the new products too
+			err = database.Sqlc.PropagateCollectionProducts(ctx, &database.PropagateCollectionProductsParams{
+				CreatedAt:    now,
+				ProductIds:   productIds,
+				CollectionID: id,
+			})
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+		}
+
+		return render("collection_add_result", result)
+
+	case "remove":
+		if args == "" {
+			return render("collection_usage", nil)
+		}
+
+		data := struct {
+			Name    string
+			Product string
+		}{
+			Name:    collection.Name,
+			Product: args,
+		}
+
+		match, err := matchProduct(ctx, args)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if match == nil || len(match.Candidates) > 0 {
+			return render("collection_product_not_found", data)
+		}
+		data.Product = match.Label
+
+		// Subscribers keep watching it, it may not be theirs to drop
+		rows, err := database.Sqlc.DeleteCollectionProduct(ctx, &database.DeleteCollectionProductParams{
+			CollectionID: id,
+			ProductID:    match.ID,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if rows == 0 {
+			return render("collection_product_not_found", data)
+		}
+		return render("collection_product_removed", data)
+
+	default: // delete
+		_, err := database.Sqlc.DeleteCollection(ctx, &database.DeleteCollectionParams{
+			ID:      id,
+			OwnerID: userId,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return render("collection_deleted", collection.Name)
+	}
+}
+
+// subscribeCollection handles "/start col_<id>": the chat watches every product of the
+// collection and the ones added to it later
+func subscribeCollection(ctx context.Context, req types.TelegramUpdate, value string) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}
+	}
+
+	id, ok := parseCollectionId(value)
+	var collection *database.Collection
+	if ok {
+		var err error
+		collection, err = getCollection(ctx, id)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+	}
+	if collection == nil {
+		text, err := message.Render(ctx, "collection_not_found", value)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	products, err := database.Sqlc.GetCollectionProducts(ctx, id)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	data := struct {
+		ID             int32
+		Name           string
+		Added          []string
+		AlreadyWatched []string
+	}{
+		ID:   collection.ID,
+		Name: collection.Name,
+	}
+	productIds := []int32{}
+	for _, product := range products {
+		if slices.ContainsFunc(watchList, func(item *database.GetWatchListRow) bool {
+			return item.ProductID == product.ID
+		}) {
+			data.AlreadyWatched = append(data.AlreadyWatched, product.Label)
+			continue
+		}
+		productIds = append(productIds, product.ID)
+		data.Added = append(data.Added, product.Label)
+	}
+
+	now := pgtype.Timestamp{Time: time.Now(), Valid: true}
+	if len(productIds) > 0 {
+		err = database.Sqlc.CreateWatchLists(ctx, &database.CreateWatchListsParams{
+			ChatID:     chatId,
+			ProductIds: productIds,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+	}
+
+	err = database.Sqlc.CreateCollectionSubscription(ctx, &database.CreateCollectionSubscriptionParams{
+		CollectionID: id,
+		ChatID:       chatId,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "collection_subscribed", data)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	return reply(text), nil
+}
+
+// getCollection returns nil if the collection doesn't exist
+func getCollection(ctx context.Context, id int32) (*database.Collection, error) {
+	collection, err := database.Sqlc.GetCollection(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.NewError(err)
+	}
+	return collection, nil
+}
+
+func parseCollectionId(s string) (int32, bool) {
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// collectionShare returns the link and the command subscribing to a collection, the link
+// is empty if the bot username is unknown
+func collectionShare(id int32, ownerId int64) (string, string) {
+	payload, err := (&deeplink.Payload{
+		Kind:     deeplink.KindCollection,
+		Value:    strconv.Itoa(int(id)),
+		Referrer: ownerId,
+	}).Encode()
+	if err != nil {
+		return "", ""
+	}
+	return deeplink.Link(payload), deeplink.Command(payload)
+}
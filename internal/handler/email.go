@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/notifier"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxEmailSubscriptions      = 5
+	maxEmailVerificationTrials = 5
+
+	// Confirmation codes sent per address and per chat within the window, so the bot can't
+	// be used to flood an inbox
+	maxEmailVerificationsPerEmail = 3
+	maxEmailVerificationsPerChat  = 5
+	emailVerificationWindow       = time.Hour
+)
+
+type emailVerificationData struct {
+	ID     int32 `json:"id"`
+	Trials int   `json:"trials"`
+}
+
+var emailFlow = &conversation.Flow[emailVerificationData]{
+	Command: "email",
+	First:   1,
+	Steps: map[int16]conversation.Step[emailVerificationData]{
+		1: {Handle: emailStepList},
+		2: {Handle: emailStepSubscribe},
+		3: {Handle: emailStepVerify},
+	},
+}
+
+func Email(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	if !service.IsEmailEnabled() {
+		text, err := message.Render(ctx, "email_unavailable", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	return emailFlow.Run(ctx, req)
+}
+
+// emailStepList lists the email subscriptions
+func emailStepList(ctx context.Context, req types.TelegramUpdate, _ *emailVerificationData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	subscriptions, err := database.Sqlc.GetEmailSubscriptionsByChat(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "email_list", subscriptions)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, len(subscriptions)+1) // +1 for cancel button
+	for _, subscription := range subscriptions {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_remove_email", subscription.Email),
+				CallbackData: fmt.Sprintf("remove_%d", subscription.ID),
+			},
+		})
+	}
+	inlineKeyboard = append(inlineKeyboard, cancelReplyMarkup(ctx).InlineKeyboard...)
+
+	resp, err := buttonsPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(2, resp), nil
+}
+
+// emailStepSubscribe sends a confirmation code to the sent address, or removes the chosen one
+func emailStepSubscribe(ctx context.Context, req types.TelegramUpdate, state *emailVerificationData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
+
+	if strings.HasPrefix(req.CallbackQuery.Data, "remove_") {
+		subscriptionId, err := strconv.ParseInt(strings.TrimPrefix(req.CallbackQuery.Data, "remove_"), 10, 32)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Delete email subscription
+		err = database.Sqlc.DeleteEmailSubscription(ctx, &database.DeleteEmailSubscriptionParams{
+			ID:     int32(subscriptionId),
+			ChatID: chatId,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Answer callback query
+		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "email_removed", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// It must be text message
+	if req.Message.Text == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	address, err := mail.ParseAddress(strings.TrimSpace(req.Message.Text))
+	if err != nil || len(address.Address) > 320 {
+		text, err := message.Render(ctx, "email_invalid", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	// An address that was already added doesn't count, its code can always be sent again
+	count, err := database.Sqlc.CountEmailSubscriptions(ctx, &database.CountEmailSubscriptionsParams{
+		ChatID: chatId,
+		Email:  strings.ToLower(address.Address),
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	if count >= maxEmailSubscriptions {
+		text, err := message.Render(ctx, "email_limit", maxEmailSubscriptions)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	allowed, err := canSendEmailVerification(ctx, chatId, strings.ToLower(address.Address))
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	if !allowed {
+		text, err := message.Render(ctx, "email_too_many_codes", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	tokenB := make([]byte, 32)
+	if _, err := rand.Read(tokenB); err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	subscription, err := database.Sqlc.CreateEmailSubscription(ctx, &database.CreateEmailSubscriptionParams{
+		ChatID:           chatId,
+		Email:            strings.ToLower(address.Address),
+		VerificationCode: code,
+		UnsubscribeToken: hex.EncodeToString(tokenB),
+		CreatedAt:        pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if subscription.VerifiedAt.Valid {
+		text, err := message.Render(ctx, "email_already_confirmed", subscription.Email)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	// Send confirmation code
+	err = database.Sqlc.CreateEmailVerification(ctx, &database.CreateEmailVerificationParams{
+		ChatID:    chatId,
+		Email:     subscription.Email,
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	err = notifier.SendEmailVerification(ctx, subscription)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	*state = emailVerificationData{ID: subscription.ID}
+
+	text, err := message.Render(ctx, "email_code_sent", subscription.Email)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(3, textPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	})), nil
+}
+
+// emailStepVerify confirms the subscription with the code
+func emailStepVerify(ctx context.Context, req types.TelegramUpdate, state *emailVerificationData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
+
+	subscription, err := database.Sqlc.GetEmailSubscription(ctx, &database.GetEmailSubscriptionParams{
+		ID:     state.ID,
+		ChatID: chatId,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	code := strings.TrimSpace(req.Message.Text)
+	if subtle.ConstantTimeCompare([]byte(code), []byte(subscription.VerificationCode)) != 1 {
+		state.Trials++
+		if state.Trials >= maxEmailVerificationTrials {
+			text, err := message.Render(ctx, "email_too_many_trials", nil)
+			if err != nil {
+				return conversation.Transition{}, utils.NewError(err)
+			}
+
+			return conversation.End(&types.TelegramResponse{
+				Method:      types.TelegramMethodSendMessage,
+				ChatId:      chatId,
+				ParseMode:   types.TelegramParseModeHTML,
+				Text:        text,
+				ReplyMarkup: message.DefaultReplyMarkup(ctx),
+			}), nil
+		}
+
+		text, err := message.Render(ctx, "email_invalid_code", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	err = database.Sqlc.VerifyEmailSubscription(ctx, &database.VerifyEmailSubscriptionParams{
+		VerifiedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		ID:         subscription.ID,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "email_confirmed", subscription.Email)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}), nil
+}
+
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// canSendEmailVerification reports whether another confirmation code can be sent to the address
+// from the chat
+func canSendEmailVerification(ctx context.Context, chatId int64, email string) (bool, error) {
+	since := pgtype.Timestamp{Time: time.Now().Add(-emailVerificationWindow), Valid: true}
+
+	count, err := database.Sqlc.CountEmailVerificationsByEmail(ctx, &database.CountEmailVerificationsByEmailParams{
+		Email:     email,
+		CreatedAt: since,
+	})
+	if err != nil {
+		return false, utils.NewError(err)
+	}
+	if count >= maxEmailVerificationsPerEmail {
+		return false, nil
+	}
+
+	count, err = database.Sqlc.CountEmailVerificationsByChat(ctx, &database.CountEmailVerificationsByChatParams{
+		ChatID:    chatId,
+		CreatedAt: since,
+	})
+	if err != nil {
+		return false, utils.NewError(err)
+	}
+
+	return count < maxEmailVerificationsPerChat, nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/export"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Export sends the watch list as a file which /import accepts, "/export csv" for CSV and JSON otherwise
+func Export(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	format := export.FormatJSON
+	if fields := strings.Fields(req.Message.Text); len(fields) > 1 && strings.EqualFold(fields[1], export.FormatCSV) {
+		format = export.FormatCSV
+	}
+
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	if len(watchList) == 0 {
+		text, err := message.Render(ctx, "watch_list_header", 0)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	exported := export.WatchList{
+		Version:    export.Version,
+		ExportedAt: time.Now().UTC(),
+		Products:   make([]export.Product, 0, len(watchList)),
+	}
+	for _, item := range watchList {
+		exported.Products = append(exported.Products, export.Product{
+			Name:         item.ProductName,
+			Label:        item.ProductLabel,
+			WatchedSince: item.CreatedAt.Time,
+		})
+	}
+
+	// The language belongs to the user, not to the group
+	if req.IsPrivateChat() {
+		language, err := database.Sqlc.GetUserLanguage(ctx, req.UserId())
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.NewError(err)
+		}
+		if language != nil {
+			exported.Language = *language
+		}
+	}
+
+	content, err := export.Marshal(&exported, format)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	caption, err := message.Render(ctx, "export_caption", len(exported.Products))
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	err = service.SendDocument(ctx, &service.SendDocumentParams{
+		ChatId:    chatId,
+		FileName:  export.FileName(format),
+		Content:   content,
+		Caption:   caption,
+		ParseMode: service.TelegramParseModeHTML,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	return nil, nil
+}
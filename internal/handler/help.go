@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/commands"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Help lists the commands available in the chat, from the command registry
+func Help(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	scope := commands.ScopeGroup
+	if req.IsPrivateChat() {
+		scope = commands.ScopePrivate
+	}
+
+	text, err := message.Render(ctx, "help", commands.Describe(ctx, scope))
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      req.ChatId(),
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}, nil
+}
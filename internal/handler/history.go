@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Number of versions per page of history
+const historyPageSize = 15
+
+type historyCycle struct {
+	Label    string
+	Versions []historyVersion
+}
+
+type historyVersion struct {
+	Version    string
+	Date       string
+	Link       string
+	DetectedAt string
+}
+
+// History replies to "/history <product>" with the versions detected so far, latest cycle first
+func History(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	keyword := strings.Join(strings.Fields(req.Message.Text)[1:], " ")
+	if keyword == "" {
+		text, err := message.Render(ctx, "history_usage", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	// Best match
+	products, err := database.Sqlc.SearchProducts(ctx, &database.SearchProductsParams{
+		Keyword:    keyword,
+		PageSize:   1,
+		PageOffset: 0,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if len(products) == 0 {
+		text, err := message.Render(ctx, "history_product_not_found", keyword)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	text, replyMarkup, err := historyPage(ctx, products[0].ID, products[0].Label, products[0].EolUrl, 0)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	resp := &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+			IsDisabled: true,
+		},
+	}
+	if replyMarkup != nil {
+		resp.ReplyMarkup = replyMarkup
+	}
+	return resp, nil
+}
+
+// HistoryPage handles the navigation buttons of the history, "history_<product id>_<page>"
+func HistoryPage(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	var productId int32
+	var page int
+	_, err := fmt.Sscanf(req.CallbackQuery.Data, "history_%d_%d", &productId, &page)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	product, err := database.Sqlc.GetProductById(ctx, productId)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	text, replyMarkup, err := historyPage(ctx, product.ID, product.Label, product.EolUrl, max(page, 0))
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	resp := &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+			IsDisabled: true,
+		},
+	}
+	if replyMarkup != nil {
+		resp.ReplyMarkup = replyMarkup
+	}
+	return resp, nil
+}
+
+// historyPage renders a page of the history of a product, versions are grouped by release cycle.
+// The reply markup is nil if the history fits in one page.
+func historyPage(ctx context.Context, productId int32, label string, url string, page int) (string, *types.TelegramInlineKeyboardMarkup, error) {
+	versions, err := database.Sqlc.GetProductVersionHistory(ctx, &database.GetProductVersionHistoryParams{
+		ProductID:  productId,
+		PageSize:   historyPageSize,
+		PageOffset: int32(page * historyPageSize),
+	})
+	if err != nil {
+		return "", nil, utils.NewError(err)
+	}
+
+	// History may have been cleaned up since the previous page
+	if len(versions) == 0 && page > 0 {
+		return historyPage(ctx, productId, label, url, 0)
+	}
+
+	if len(versions) == 0 {
+		text, err := message.Render(ctx, "history_empty", label)
+		if err != nil {
+			return "", nil, utils.NewError(err)
+		}
+		return text, nil, nil
+	}
+
+	var cycles []historyCycle
+	for _, v := range versions {
+		if len(cycles) == 0 || cycles[len(cycles)-1].Label != v.ReleaseLabel {
+			cycles = append(cycles, historyCycle{Label: v.ReleaseLabel})
+		}
+		version := historyVersion{
+			Version:    v.Version,
+			Date:       formatTimestamp(v.VersionReleaseDate),
+			DetectedAt: formatTimestamp(v.CreatedAt),
+		}
+		if v.VersionReleaseLink != nil {
+			version.Link = *v.VersionReleaseLink
+		}
+		cycles[len(cycles)-1].Versions = append(cycles[len(cycles)-1].Versions, version)
+	}
+
+	pages := (int(versions[0].Total) + historyPageSize - 1) / historyPageSize
+
+	text, err := message.Render(ctx, "history", struct {
+		Label  string
+		Url    string
+		Page   int
+		Pages  int
+		Cycles []historyCycle
+	}{
+		Label:  label,
+		Url:    url,
+		Page:   page + 1,
+		Pages:  pages,
+		Cycles: cycles,
+	})
+	if err != nil {
+		return "", nil, utils.NewError(err)
+	}
+
+	var navigation []types.TelegramInlineKeyboardButton
+	if page > 0 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_prev", nil),
+			CallbackData: fmt.Sprintf("history_%d_%d", productId, page-1),
+		})
+	}
+	if page < pages-1 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_next", nil),
+			CallbackData: fmt.Sprintf("history_%d_%d", productId, page+1),
+		})
+	}
+	if len(navigation) == 0 {
+		return text, nil, nil
+	}
+
+	return text, &types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: [][]types.TelegramInlineKeyboardButton{navigation},
+	}, nil
+}
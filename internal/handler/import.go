@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/export"
+	"github.com/fidrasofyan/version-watcher-bot/internal/manifest"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	importCommand = "import"
+
+	// Dependency files are small, anything bigger is unlikely to be one
+	importMaxFileSize = 1 << 20
+
+	// The plan is outdated once the watch list changes, confirm it soon
+	importConfirmTimeout = 10 * time.Minute
+)
+
+type importData struct {
+	ProductIds []int32 `json:"product_ids"`
+	Language   string  `json:"language"`
+}
+
+var importFlow = &conversation.Flow[importData]{
+	Command: importCommand,
+	First:   1,
+	Steps: map[int16]conversation.Step[importData]{
+		1: {Handle: importStepFile},
+		2: {Timeout: importConfirmTimeout, Handle: importStepConfirm},
+	},
+}
+
+// Import adds the products a dependency file (go.mod, package.json, Dockerfile, etc.) uses to the watch list.
+// It also restores a watch list sent by /export, to the same or another chat.
+func Import(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	// A new file always starts over
+	if req.Message.Document.FileId != "" {
+		return importFlow.Restart(ctx, req)
+	}
+	return importFlow.Run(ctx, req)
+}
+
+// importStepFile reads the file and asks to confirm what it would change
+func importStepFile(ctx context.Context, req types.TelegramUpdate, data *importData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+	document := req.Message.Document
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}
+	}
+
+	// Wait for the file, in a group only a reply or a file with /import as caption reaches the bot
+	if document.FileId == "" {
+		text, err := message.Render(ctx, "import_prompt", req.IsGroupChat())
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	plan, err := importFile(ctx, document)
+	if err != nil {
+		var importErr *importError
+		if !errors.As(err, &importErr) {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, importErr.template, importErr.data)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		return conversation.End(reply(text)), nil
+	}
+
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	data.ProductIds = []int32{}
+	var labels, alreadyWatched []string
+	for _, product := range plan.Products {
+		if slices.ContainsFunc(watchList, func(item *database.GetWatchListRow) bool {
+			return item.ProductID == product.ID
+		}) {
+			alreadyWatched = append(alreadyWatched, product.Label)
+			continue
+		}
+		data.ProductIds = append(data.ProductIds, product.ID)
+		labels = append(labels, product.Label)
+	}
+
+	// The language belongs to the user, not to the group
+	if plan.Language != "" && req.IsPrivateChat() {
+		language, err := database.Sqlc.GetUserLanguage(ctx, userId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		if language == nil || *language != plan.Language {
+			data.Language = plan.Language
+		}
+	}
+
+	if len(data.ProductIds) == 0 && data.Language == "" {
+		text, err := message.Render(ctx, "import_nothing_to_add", struct {
+			FileName       string
+			AlreadyWatched []string
+		}{
+			FileName:       document.FileName,
+			AlreadyWatched: alreadyWatched,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		return conversation.End(reply(text)), nil
+	}
+
+	text, err := message.Render(ctx, "import_confirm", struct {
+		FileName       string
+		Products       []string
+		AlreadyWatched []string
+		Unknown        int
+		NotFound       []string
+		Language       string
+	}{
+		FileName:       document.FileName,
+		Products:       labels,
+		AlreadyWatched: alreadyWatched,
+		Unknown:        plan.Unknown,
+		NotFound:       plan.NotFound,
+		Language:       languageName(ctx, data.Language),
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	confirmText := message.Text(ctx, "button_apply", nil)
+	if len(data.ProductIds) > 0 {
+		confirmText = message.Text(ctx, "button_watch_products", len(data.ProductIds))
+	}
+
+	return conversation.Next(2, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
+				{
+					{
+						Text:         confirmText,
+						CallbackData: "confirm",
+					},
+				},
+				{
+					{
+						Text:         message.Text(ctx, "button_cancel", nil),
+						CallbackData: "cancel",
+					},
+				},
+			},
+		},
+	}), nil
+}
+
+// importStepConfirm applies the import once confirmed
+func importStepConfirm(ctx context.Context, req types.TelegramUpdate, data *importData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	// It must be callback query
+	if req.CallbackQuery.Data == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// Answer callback query
+	err := service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if req.CallbackQuery.Data != "confirm" {
+		text, err := message.Render(ctx, "canceled", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// Add to watch list
+	err = database.Sqlc.CreateWatchLists(ctx, &database.CreateWatchListsParams{
+		ChatID:     chatId,
+		ProductIds: data.ProductIds,
+		CreatedAt:  pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if data.Language != "" {
+		// Update user language
+		err = database.Sqlc.UpdateUserLanguage(ctx, &database.UpdateUserLanguageParams{
+			Language: &data.Language,
+			ID:       userId,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		ctx = message.WithLocale(ctx, data.Language)
+	}
+
+	text, err := message.Render(ctx, "import_done", struct {
+		Added    int
+		Language string
+	}{
+		Added:    len(data.ProductIds),
+		Language: languageName(ctx, data.Language),
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}), nil
+}
+
+// importError is a problem with the file itself, shown to the user with the template
+type importError struct {
+	template string
+	data     any
+}
+
+func (e *importError) Error() string {
+	return e.template
+}
+
+// importPlan is what an imported file would change, shown before applying it
+type importPlan struct {
+	Products []*database.GetProductsByNamesRow
+	// Dependencies of a dependency file that aren't products
+	Unknown int
+	// Products of an exported watch list that no longer exist
+	NotFound []string
+	// Language of an exported watch list
+	Language string
+}
+
+// isImportFile reports whether a file looks like a dependency file or an exported watch list
+func isImportFile(fileName string) bool {
+	if _, err := manifest.Kind(fileName); err == nil {
+		return true
+	}
+	_, err := export.Format(fileName)
+	return err == nil
+}
+
+// importFile downloads and parses a dependency file or an exported watch list
+func importFile(ctx context.Context, document types.TelegramDocument) (*importPlan, error) {
+	if document.FileSize > importMaxFileSize {
+		return nil, &importError{template: "import_too_large", data: importMaxFileSize >> 10}
+	}
+	if !isImportFile(document.FileName) {
+		return nil, &importError{template: "import_unsupported", data: document.FileName}
+	}
+
+	file, err := service.GetFile(ctx, &service.GetFileParams{
+		FileId: document.FileId,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	content, err := service.DownloadFile(ctx, file, importMaxFileSize)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	plan := importPlan{}
+	var names []string
+	_, err = manifest.Kind(document.FileName)
+	isManifest := err == nil
+	if isManifest {
+		names, err = manifest.Parse(document.FileName, content)
+		if err != nil {
+			return nil, &importError{template: "import_invalid", data: document.FileName}
+		}
+	} else {
+		watchList, err := export.Parse(document.FileName, content)
+		if err != nil {
+			return nil, &importError{template: "import_invalid", data: document.FileName}
+		}
+		for _, product := range watchList.Products {
+			names = append(names, product.Name)
+		}
+		plan.Language = message.ResolveLocale(watchList.Language)
+	}
+
+	plan.Products, err = database.Sqlc.GetProductsByNames(ctx, names)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if len(plan.Products) == 0 && plan.Language == "" {
+		return nil, &importError{template: "import_nothing_found", data: document.FileName}
+	}
+
+	if isManifest {
+		plan.Unknown = len(names) - len(plan.Products)
+	} else {
+		for _, name := range names {
+			if !slices.ContainsFunc(plan.Products, func(product *database.GetProductsByNamesRow) bool {
+				return product.Name == name
+			}) {
+				plan.NotFound = append(plan.NotFound, name)
+			}
+		}
+	}
+
+	return &plan, nil
+}
+
+// languageName returns the name of a locale in that language, or an empty string for no locale
+func languageName(ctx context.Context, locale string) string {
+	if locale == "" {
+		return ""
+	}
+	return message.Text(message.WithLocale(ctx, locale), "language_name", nil)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// Each result fetches the product from endoflife.date, keep it small
+	inlineResultsLimit = 5
+	// Number of release cycles shown per product
+	inlineCyclesLimit = 5
+	// Results are in the language of the user, let Telegram cache them per user
+	inlineCacheTime = 300
+)
+
+// InlineQuery answers "@bot <keyword>" typed in any chat with the matching products
+func InlineQuery(ctx context.Context, req types.TelegramUpdate) error {
+	keyword := strings.TrimSpace(req.InlineQuery.Query)
+	offset, _ := strconv.Atoi(req.InlineQuery.Offset)
+
+	results := []types.TelegramInlineQueryResultArticle{}
+	nextOffset := ""
+
+	if len(keyword) >= 2 {
+		products, err := database.Sqlc.SearchProducts(ctx, &database.SearchProductsParams{
+			Keyword:    keyword,
+			PageSize:   inlineResultsLimit,
+			PageOffset: int32(offset),
+		})
+		if err != nil {
+			return utils.NewError(err)
+		}
+
+		// Fetch release cycles of all products at once
+		details := make([]*service.EndOfLifeProduct, len(products))
+		var wg sync.WaitGroup
+		for i, product := range products {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				detail, err := service.GetCachedEndOfLifeProduct(ctx, product.ApiUrl)
+				if err != nil {
+					// Show the product without release cycles
+					log.Printf("InlineQuery: %s: %v", product.Name, err)
+					return
+				}
+				details[i] = detail
+			}()
+		}
+		wg.Wait()
+
+		for i, product := range products {
+			item := productInfo{
+				Label: product.Label,
+				Url:   product.EolUrl,
+			}
+			if details[i] != nil {
+				item.Cycles = productCycles(details[i].Releases, inlineCyclesLimit)
+			}
+
+			text, err := message.Render(ctx, "inline_product", item)
+			if err != nil {
+				return utils.NewError(err)
+			}
+
+			results = append(results, types.TelegramInlineQueryResultArticle{
+				Type:        "article",
+				Id:          fmt.Sprint(product.ID),
+				Title:       product.Label,
+				Description: message.Text(ctx, "inline_product_description", item),
+				InputMessageContent: types.TelegramInputTextMessageContent{
+					MessageText: text,
+					ParseMode:   types.TelegramParseModeHTML,
+					LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+						IsDisabled: true,
+					},
+				},
+				ReplyMarkup: &types.TelegramInlineKeyboardMarkup{
+					InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
+						{
+							{
+								Text:         message.Text(ctx, "button_add_to_watch_list", nil),
+								CallbackData: fmt.Sprintf("watch_%d", product.ID),
+							},
+						},
+					},
+				},
+			})
+		}
+
+		if len(products) > 0 && offset+len(products) < int(products[0].Total) {
+			nextOffset = fmt.Sprint(offset + len(products))
+		}
+	}
+
+	err := service.AnswerInlineQuery(ctx, &service.AnswerInlineQueryParams{
+		InlineQueryId: req.InlineQuery.Id,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+		NextOffset:    nextOffset,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
+
+// InlineWatch adds the product of a message sent via inline mode to the watch list of the user
+// who pressed the button
+func InlineWatch(ctx context.Context, req types.TelegramUpdate) error {
+	userId := req.UserId()
+
+	answer := func(text string) error {
+		showAlert := true
+		return service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+			Text:            &text,
+			ShowAlert:       &showAlert,
+		})
+	}
+
+	productId64, err := strconv.ParseInt(strings.TrimPrefix(req.CallbackQuery.Data, "watch_"), 10, 32)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	productId := int32(productId64)
+
+	// The bot can't notify users who haven't started it
+	isUserExists, err := database.Sqlc.IsUserExists(ctx, userId)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	if !isUserExists {
+		err = answer(message.Text(ctx, "inline_start_first", nil))
+		if err != nil {
+			return utils.NewError(err)
+		}
+		return nil
+	}
+
+	product, err := database.Sqlc.GetProductById(ctx, productId)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	// Add to watch list, nothing is added if it's already there. Private chats share the id of the user
+	rows, err := database.Sqlc.CreateWatchList(ctx, &database.CreateWatchListParams{
+		ChatID:    userId,
+		ProductID: productId,
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+	if rows == 0 {
+		err = answer(message.Text(ctx, "inline_already_watched", product.Label))
+		if err != nil {
+			return utils.NewError(err)
+		}
+		return nil
+	}
+
+	err = answer(message.Text(ctx, "inline_watch_added", product.Label))
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+var languageFlow = &conversation.Flow[struct{}]{
+	Command: "language",
+	First:   1,
+	Steps: map[int16]conversation.Step[struct{}]{
+		1: {Handle: languageStepChoose},
+		2: {Handle: languageStepSave},
+	},
+}
+
+func Language(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	// Channels are not users
+	if req.ChatType() == types.TelegramChatTypeChannel {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		return &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}, nil
+	}
+
+	return languageFlow.Run(ctx, req)
+}
+
+// languageStepChoose lists the languages
+func languageStepChoose(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	locales := message.Locales()
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, len(locales)+1) // +1 for cancel button
+	for _, locale := range locales {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(message.WithLocale(ctx, locale), "language_name", nil),
+				CallbackData: "language_" + locale,
+			},
+		})
+	}
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text:         message.Text(ctx, "button_cancel", nil),
+			CallbackData: "cancel",
+		},
+	})
+
+	text, err := message.Render(ctx, "language_choose", nil)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(2, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	}), nil
+}
+
+// languageStepSave saves the chosen language
+func languageStepSave(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	// It must be callback query
+	if req.CallbackQuery.Data == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// Answer callback query
+	err := service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	locale := message.ResolveLocale(strings.TrimPrefix(req.CallbackQuery.Data, "language_"))
+	if req.CallbackQuery.Data == "cancel" || locale == "" {
+		text, err := message.Render(ctx, "canceled", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// Save setting
+	err = registerUser(ctx, req.CallbackQuery.From, 0)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	err = database.Sqlc.UpdateUserLanguage(ctx, &database.UpdateUserLanguageParams{
+		Language: &locale,
+		ID:       userId,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	// Reply in the new language
+	ctx = message.WithLocale(ctx, locale)
+	text, err := message.Render(ctx, "language_set", message.Text(ctx, "language_name", nil))
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	// The reply keyboard can't be changed by editing the message
+	return conversation.End(&types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}), nil
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
 	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
@@ -13,7 +14,7 @@ func NotFound(ctx context.Context, req types.TelegramUpdate) (*types.TelegramRes
 	// Is it callback query?
 	if req.CallbackQuery.Id != "" {
 		// Delete chat
-		err := repository.TelegramDeleteChat(ctx, req.CallbackQuery.From.Id)
+		err := repository.TelegramDeleteChat(ctx, req.ChatId(), req.UserId())
 		if err != nil {
 			return nil, utils.NewError(err)
 		}
@@ -26,20 +27,30 @@ func NotFound(ctx context.Context, req types.TelegramUpdate) (*types.TelegramRes
 			return nil, utils.NewError(err)
 		}
 
+		text, err := message.Render(ctx, "invalid_session", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
 		return &types.TelegramResponse{
 			Method:    types.TelegramMethodEditMessageText,
 			MessageId: req.CallbackQuery.Message.MessageId,
 			ChatId:    req.CallbackQuery.Message.Chat.Id,
 			ParseMode: types.TelegramParseModeHTML,
-			Text:      "<i>Invalid session</i>",
+			Text:      text,
 		}, nil
 	}
 
+	text, err := message.Render(ctx, "unknown_command", nil)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
 	return &types.TelegramResponse{
 		Method:      types.TelegramMethodSendMessage,
 		ChatId:      req.Message.Chat.Id,
 		ParseMode:   types.TelegramParseModeHTML,
-		Text:        "<i>Unknown command</i>",
-		ReplyMarkup: types.DefaultReplyMarkup,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
 	}, nil
 }
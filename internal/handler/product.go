@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type productInfo struct {
+	Label  string
+	Url    string
+	Cycles []productCycle
+}
+
+type productCycle struct {
+	Name    string
+	Label   string
+	Version string
+	Date    string
+	Link    string
+	Eol     bool
+	EolDate string
+}
+
+// productCycles returns up to limit release cycles, latest first, with their EOL status
+func productCycles(releases []service.EndOfLifeRelease, limit int) []productCycle {
+	releases = releases[:min(len(releases), limit)]
+	cycles := make([]productCycle, len(releases))
+	for i, release := range releases {
+		cycles[i] = productCycle{
+			Name:    release.Name,
+			Label:   release.Label,
+			Version: "-",
+			Eol:     release.IsEol,
+		}
+		if release.Latest != nil {
+			if release.Latest.Name != nil {
+				cycles[i].Version = *release.Latest.Name
+			}
+			if release.Latest.Date != nil {
+				cycles[i].Date = formatDate(*release.Latest.Date)
+			}
+			if release.Latest.Link != nil {
+				cycles[i].Link = *release.Latest.Link
+			}
+		}
+		if release.Custom != nil && release.Custom.APIVersion != nil {
+			cycles[i].Version = *release.Custom.APIVersion
+		}
+		if release.EolFrom != nil {
+			cycles[i].EolDate = formatDate(*release.EolFrom)
+		}
+	}
+	return cycles
+}
+
+// formatDate formats a YYYY-MM-DD date as displayed in messages (e.g. 8 Aug 2024)
+func formatDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("2 Jan 2006")
+}
+
+// formatTimestamp is formatDate for dates read from the database, invalid ones are empty
+func formatTimestamp(ts pgtype.Timestamp) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.Format("2 Jan 2006")
+}
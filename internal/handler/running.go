@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/running"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Maximum length of a running version, as stored in watch_lists
+const runningVersionMaxLength = 50
+
+// Running records the version of a watched product the chat runs, "/running <product> <version>".
+// Without version, the running version is cleared.
+func Running(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}
+	}
+
+	args := strings.Fields(req.Message.Text)[1:]
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && len(args[1]) > runningVersionMaxLength) {
+		text, err := message.Render(ctx, "running_usage", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+	keyword := args[0]
+	var version *string
+	if len(args) == 2 {
+		version = &args[1]
+	}
+
+	// Only watched products have a row to store it on
+	match, err := matchWatchedProduct(ctx, chatId, keyword)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if match == nil {
+		// Tell a product which isn't watched from one which doesn't exist
+		match, err = matchProduct(ctx, keyword)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if match == nil {
+			text, err := message.Render(ctx, "version_product_not_found", keyword)
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+			return reply(text), nil
+		}
+
+		label := match.Label
+		if len(match.Candidates) > 0 {
+			label = keyword
+		}
+		text, err := message.Render(ctx, "running_not_watched", label)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+	if len(match.Candidates) > 0 {
+		text, err := message.Render(ctx, "running_ambiguous", watchBulkAmbiguous{
+			Keyword:    keyword,
+			Candidates: match.Candidates,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	product, err := database.Sqlc.GetProductById(ctx, match.ID)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	_, err = database.Sqlc.SetRunningVersion(ctx, &database.SetRunningVersionParams{
+		RunningVersion: version,
+		ChatID:         chatId,
+		ProductID:      product.ID,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	if version == nil {
+		text, err := message.Render(ctx, "running_cleared", product.Label)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	status, err := running.Check(ctx, product.ID, product.ApiUrl, *version)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "running_set", struct {
+		Label  string
+		Status *running.Status
+	}{
+		Label:  product.Label,
+		Status: status,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	return reply(text), nil
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/deeplink"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Share generates deep links crediting the user as referrer: "/share <product>" opens the
+// watch flow of the product, "/share" alone invites people to the bot.
+func Share(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+	userId := req.UserId()
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}
+	}
+
+	// Drop the command
+	_, keyword, _ := strings.Cut(req.Message.Text, " ")
+	keyword = strings.TrimSpace(keyword)
+
+	// Channel posts have no sender to credit
+	var referrer int64
+	if req.ChatType() != types.TelegramChatTypeChannel {
+		referrer = userId
+	}
+
+	if keyword == "" {
+		if referrer == 0 {
+			text, err := message.Render(ctx, "share_usage", nil)
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+			return reply(text), nil
+		}
+
+		payload, err := (&deeplink.Payload{
+			Kind:     deeplink.KindReferral,
+			Referrer: referrer,
+		}).Encode()
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		referrals, err := database.Sqlc.CountReferrals(ctx, referrer)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "share_referral", struct {
+			Link      string
+			Start     string
+			Referrals int64
+		}{
+			Link:      deeplink.Link(payload),
+			Start:     deeplink.Command(payload),
+			Referrals: referrals,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	match, err := matchProduct(ctx, keyword)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if match == nil {
+		text, err := message.Render(ctx, "version_product_not_found", keyword)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+	if len(match.Candidates) > 0 {
+		text, err := message.Render(ctx, "share_ambiguous", watchBulkAmbiguous{
+			Keyword:    keyword,
+			Candidates: match.Candidates,
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+
+	data := struct {
+		Label string
+		Link  string
+		Start string
+	}{
+		Label: match.Label,
+	}
+	payload, err := (&deeplink.Payload{
+		Kind:     deeplink.KindWatch,
+		Value:    match.Name,
+		Referrer: referrer,
+	}).Encode()
+	// Names which don't fit in a payload can't be linked
+	if err == nil {
+		data.Link = deeplink.Link(payload)
+		data.Start = deeplink.Command(payload)
+	}
+
+	text, err := message.Render(ctx, "share_product", data)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	return reply(text), nil
+}
@@ -2,38 +2,94 @@ package handler
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/deeplink"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// Start greets the user. "/start <payload>" comes from a deep link (see package deeplink):
+// watch_<product> pre-fills the watch flow, col_<id> subscribes to a collection and
+// the referrer of any link is recorded for new users.
 func Start(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
-	exists, err := database.Sqlc.IsUserExists(ctx, req.Message.Chat.Id)
-	if err != nil {
-		return nil, utils.NewError(err)
-	}
+	// Deep link payload, an invalid one is ignored
+	_, rawPayload, _ := strings.Cut(req.Message.Text, " ")
+	payload, _ := deeplink.Parse(strings.TrimSpace(rawPayload))
 
-	if !exists {
-		_, err := database.Sqlc.CreateUser(ctx, &database.CreateUserParams{
-			ID:        req.Message.Chat.Id,
-			Username:  &req.Message.Chat.Username,
-			FirstName: &req.Message.Chat.FirstName,
-			LastName:  &req.Message.Chat.LastName,
-			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
-		})
+	// Register the sender, channels are not users
+	if req.ChatType() != types.TelegramChatTypeChannel {
+		var referrer int64
+		if payload != nil {
+			referrer = payload.Referrer
+		}
+		err := registerUser(ctx, req.Message.From, referrer)
 		if err != nil {
 			return nil, utils.NewError(err)
 		}
 	}
 
+	if payload != nil {
+		switch payload.Kind {
+		case deeplink.KindWatch:
+			return watchPrefill(ctx, req, payload.Value)
+		case deeplink.KindCollection:
+			return subscribeCollection(ctx, req, payload.Value)
+		}
+	}
+
+	text, err := message.Render(ctx, "start", nil)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
 	return &types.TelegramResponse{
 		Method:      types.TelegramMethodSendMessage,
 		ChatId:      req.Message.Chat.Id,
 		ParseMode:   types.TelegramParseModeHTML,
-		Text:        "Welcome to Version Watcher. Type /help to see the list of available commands.",
-		ReplyMarkup: types.DefaultReplyMarkup,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
 	}, nil
 }
+
+// registerUser creates the user on first contact. The referrer, 0 if unknown, is only
+// recorded then.
+func registerUser(ctx context.Context, from types.TelegramUser, referrer int64) error {
+	exists, err := database.Sqlc.IsUserExists(ctx, from.Id)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	if exists {
+		return nil
+	}
+
+	var languageCode *string
+	if from.LanguageCode != "" {
+		languageCode = &from.LanguageCode
+	}
+
+	// Users can't refer themselves
+	var referredBy *int64
+	if referrer != 0 && referrer != from.Id {
+		referredBy = &referrer
+	}
+
+	_, err = database.Sqlc.CreateUser(ctx, &database.CreateUserParams{
+		ID:           from.Id,
+		Username:     &from.Username,
+		FirstName:    &from.FirstName,
+		LastName:     &from.LastName,
+		LanguageCode: languageCode,
+		ReferredBy:   referredBy,
+		CreatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
@@ -5,229 +5,322 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
-	"github.com/bytedance/sonic"
 	"github.com/fidrasofyan/version-watcher-bot/database"
-	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 )
 
-func UnwatchStep1(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
-	watchList, err := database.Sqlc.GetWatchList(ctx, req.Message.Chat.Id)
-	if err != nil {
-		return nil, utils.NewError(err)
-	}
-
-	textLimit := 3500
-	var textB strings.Builder
-	textB.WriteString("<b>Watch List</b>\n")
-
-	switch len(watchList) {
-	case 0:
-		textB.WriteString("\n<i>No watch list found</i>")
-	case 1:
-		textB.WriteString("<i>You watch 1 product</i>\n\n")
-	default:
-		textB.WriteString(fmt.Sprintf("<i>You watch %d products</i>\n\n", len(watchList)))
-	}
-
-	for _, watchListItem := range watchList {
-		// Normalize product name
-		productName := strings.ReplaceAll(watchListItem.ProductName, "-", "_")
-		textB.WriteString(fmt.Sprintf("• %s - /unwatch_%s\n", watchListItem.ProductLabel, productName))
-
-		// If text is too long, send it part by part
-		if textB.Len() >= textLimit {
-			service.SendMessage(ctx, &service.SendMessageParams{
-				ChatId:    req.Message.Chat.Id,
-				ParseMode: service.TelegramParseModeHTML,
-				Text:      textB.String(),
-				LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
-					IsDisabled: true,
-				},
-			})
-			textB.Reset()
-		}
-	}
+const (
+	unwatchCommand = "unwatch"
 
-	if textB.Len() == 0 {
-		return nil, nil
-	}
-
-	return &types.TelegramResponse{
-		Method:    types.TelegramMethodSendMessage,
-		ChatId:    req.Message.Chat.Id,
-		ParseMode: types.TelegramParseModeHTML,
-		Text:      textB.String(),
-	}, nil
-}
+	// Number of products per page of the watch list
+	unwatchPageSize = 10
+)
 
 type productData struct {
 	ID    int32  `json:"id"`
 	Label string `json:"label"`
 }
 
-func UnwatchStep2(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
-	var chatId int64
+var unwatchFlow = &conversation.Flow[productData]{
+	Command: unwatchCommand,
+	First:   1,
+	Steps: map[int16]conversation.Step[productData]{
+		1: {Handle: unwatchStepList},
+		2: {Handle: unwatchStepChoose},
+		3: {Handle: unwatchStepConfirm},
+	},
+}
 
-	// Is it callback query?
-	if req.CallbackQuery.Data != "" {
-		chatId = req.CallbackQuery.From.Id
-	} else {
-		chatId = req.Message.Chat.Id
+func Unwatch(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	// Switch to the selection of several products
+	if req.CallbackQuery.Data == "unwatch_select" {
+		return unwatchSelectFlow.Restart(ctx, req)
 	}
 
-	// Get chat
-	chat, err := repository.TelegramGetChat(ctx, chatId)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return nil, utils.NewError(err)
+	return unwatchFlow.Run(ctx, req)
+}
+
+// unwatchStepList shows the first page of the watch list
+func unwatchStepList(ctx context.Context, req types.TelegramUpdate, _ *productData) (conversation.Transition, error) {
+	return unwatchPage(ctx, req, 0)
+}
+
+// unwatchStepChoose goes to another page or asks to confirm the chosen product
+func unwatchStepChoose(ctx context.Context, req types.TelegramUpdate, state *productData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	if transition, ok, err := unwatchEndUnlessButton(ctx, req); ok || err != nil {
+		return transition, err
 	}
 
-	if chat == nil {
-		// Create new chat
-		chat, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: "unwatch_",
-			Step:    1,
+	// Go to another page
+	if strings.HasPrefix(req.CallbackQuery.Data, "page_") {
+		page, err := strconv.Atoi(strings.TrimPrefix(req.CallbackQuery.Data, "page_"))
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Answer callback query
+		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
 		})
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, utils.NewError(err)
 		}
+
+		return unwatchPage(ctx, req, max(page, 0))
 	}
 
-	switch chat.Step {
-	// Step 1
-	case 1:
-		productName := strings.Replace(req.Message.Text, "/unwatch_", "", 1)
-		productName = strings.ReplaceAll(productName, "_", "-")
+	productId64, err := strconv.ParseInt(req.CallbackQuery.Data, 10, 32)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
 
-		product, err := database.Sqlc.GetWatchedProductByName(ctx, &database.GetWatchedProductByNameParams{
-			Name:   productName,
-			ChatID: chatId,
-		})
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				// Delete chat
-				err := repository.TelegramDeleteChat(ctx, chatId)
-				if err != nil {
-					return nil, utils.NewError(err)
-				}
-
-				return &types.TelegramResponse{
-					Method:      types.TelegramMethodSendMessage,
-					ChatId:      req.Message.Chat.Id,
-					ParseMode:   types.TelegramParseModeHTML,
-					Text:        "<i>Product not found</i>",
-					ReplyMarkup: types.DefaultReplyMarkup,
-				}, nil
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	product, err := database.Sqlc.GetWatchedProductById(ctx, &database.GetWatchedProductByIdParams{
+		ID:     int32(productId64),
+		ChatID: chatId,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			text, err := message.Render(ctx, "unwatch_product_not_found", nil)
+			if err != nil {
+				return conversation.Transition{}, utils.NewError(err)
 			}
-			return nil, utils.NewError(err)
-		}
 
-		productData := productData{
-			ID:    product.ID,
-			Label: product.Label,
-		}
-		productDataB, err := sonic.Marshal(productData)
-		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.End(&types.TelegramResponse{
+				Method:    types.TelegramMethodEditMessageText,
+				MessageId: req.CallbackQuery.Message.MessageId,
+				ChatId:    chatId,
+				ParseMode: types.TelegramParseModeHTML,
+				Text:      text,
+			}), nil
 		}
+		return conversation.Transition{}, utils.NewError(err)
+	}
 
-		// Set step
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: "unwatch_",
-			Step:    2,
-			Data:    productDataB,
-		})
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+	*state = productData{
+		ID:    product.ID,
+		Label: product.Label,
+	}
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodSendMessage,
-			ChatId:    req.Message.Chat.Id,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      fmt.Sprintf("Are you sure you want to unwatch <b>%s</b>?", product.Label),
-			ReplyMarkup: types.TelegramReplyKeyboardMarkup{
-				ResizeKeyboard: true,
-				Keyboard: [][]string{
-					{"Yes", "No"},
+	text, err := message.Render(ctx, "unwatch_confirm", product.Label)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(3, &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
+				{
+					{
+						Text:         message.Text(ctx, "button_yes", nil),
+						CallbackData: "yes",
+					},
+					{
+						Text:         message.Text(ctx, "button_no", nil),
+						CallbackData: "cancel",
+					},
 				},
 			},
-		}, nil
+		},
+	}), nil
+}
 
-	// Step 2
-	case 2:
-		if req.Message.Text != "Yes" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
+// unwatchStepConfirm removes the product
+func unwatchStepConfirm(ctx context.Context, req types.TelegramUpdate, state *productData) (conversation.Transition, error) {
+	chatId := req.ChatId()
 
-			return &types.TelegramResponse{
-				Method:      types.TelegramMethodSendMessage,
-				ChatId:      req.Message.Chat.Id,
-				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Cancelled</i>",
-				ReplyMarkup: types.DefaultReplyMarkup,
-			}, nil
-		}
+	if transition, ok, err := unwatchEndUnlessButton(ctx, req); ok || err != nil {
+		return transition, err
+	}
 
-		// Get chat data
-		chat, err := repository.TelegramGetChat(ctx, chatId)
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+	if req.CallbackQuery.Data != "yes" {
+		return conversation.Transition{}, utils.NewError(fmt.Errorf("unexpected callback data: %s", req.CallbackQuery.Data))
+	}
 
-		// Get product data
-		productData := productData{}
-		if err := sonic.Unmarshal(chat.Data, &productData); err != nil {
-			return nil, utils.NewError(err)
-		}
+	// Delete watch list
+	err := database.Sqlc.DeleteWatchList(ctx, &database.DeleteWatchListParams{
+		ChatID:    chatId,
+		ProductID: state.ID,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
 
-		// Delete watch list
-		err = database.Sqlc.DeleteWatchList(ctx, &database.DeleteWatchListParams{
-			ChatID:    chatId,
-			ProductID: productData.ID,
-		})
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "unwatch_removed", state.Label)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
 
-		// Delete chat
-		err = repository.TelegramDeleteChat(ctx, chatId)
+	return conversation.End(&types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}), nil
+}
+
+// unwatchEndUnlessButton ends the session when the update isn't a button press, or is the
+// cancel button. Steps 2 and 3 are driven by the buttons.
+func unwatchEndUnlessButton(ctx context.Context, req types.TelegramUpdate) (conversation.Transition, bool, error) {
+	chatId := req.ChatId()
+
+	if req.CallbackQuery.Data == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, false, utils.NewError(err)
 		}
 
-		return &types.TelegramResponse{
+		return conversation.End(&types.TelegramResponse{
 			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
+			ChatId:      chatId,
 			ParseMode:   types.TelegramParseModeHTML,
-			Text:        fmt.Sprintf("<b>%s</b> removed from watch list", productData.Label),
-			ReplyMarkup: types.DefaultReplyMarkup,
-		}, nil
-
-	// Unhandled step
-	default:
-		// Delete step
-		err := repository.TelegramDeleteChat(ctx, chatId)
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), true, nil
+	}
+
+	if req.CallbackQuery.Data == "cancel" {
+		transition, err := cancelConversation(ctx, req)
+		return transition, err == nil, err
+	}
+
+	return conversation.Transition{}, false, nil
+}
+
+// unwatchPage shows a page of the watch list, one button per product.
+// It edits the message if the user pressed a button, and sends a new one otherwise.
+func unwatchPage(ctx context.Context, req types.TelegramUpdate, page int) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if len(watchList) == 0 {
+		text, err := message.Render(ctx, "watch_list_header", 0)
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, utils.NewError(err)
 		}
 
-		return &types.TelegramResponse{
+		return conversation.End(&types.TelegramResponse{
 			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
+			ChatId:      chatId,
 			ParseMode:   types.TelegramParseModeHTML,
-			Text:        "<i>Unhandled step</i>",
-			ReplyMarkup: types.DefaultReplyMarkup,
-		}, nil
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	pages := (len(watchList) + unwatchPageSize - 1) / unwatchPageSize
+	page = min(page, pages-1)
+	start := page * unwatchPageSize
+	end := min(start+unwatchPageSize, len(watchList))
 
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, end-start+3) // +3 for navigation, selection and cancel buttons
+	for _, item := range watchList[start:end] {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         item.ProductLabel,
+				CallbackData: fmt.Sprint(item.ProductID),
+			},
+		})
+	}
+
+	var navigation []types.TelegramInlineKeyboardButton
+	if page > 0 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_prev", nil),
+			CallbackData: fmt.Sprintf("page_%d", page-1),
+		})
 	}
+	if page < pages-1 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_next", nil),
+			CallbackData: fmt.Sprintf("page_%d", page+1),
+		})
+	}
+	if len(navigation) > 0 {
+		inlineKeyboard = append(inlineKeyboard, navigation)
+	}
+
+	if len(watchList) > 1 {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_select_products", nil),
+				CallbackData: "unwatch_select",
+			},
+		})
+	}
+
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text:         message.Text(ctx, "button_cancel", nil),
+			CallbackData: "cancel",
+		},
+	})
+
+	text, err := message.Render(ctx, "unwatch_choose", struct {
+		Page  int
+		Pages int
+	}{
+		Page:  page + 1,
+		Pages: pages,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if req.CallbackQuery.Id == "" {
+		return conversation.Next(2, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+				InlineKeyboard: inlineKeyboard,
+			},
+		}), nil
+	}
+
+	return conversation.Next(2, &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	}), nil
 }
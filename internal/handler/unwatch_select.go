@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+const (
+	unwatchSelectCommand = "unwatch_select"
+
+	// Number of products per page of the selection
+	unwatchSelectPageSize = 10
+)
+
+type unwatchSelectData struct {
+	Selected []int32 `json:"selected"`
+	Page     int     `json:"page"`
+}
+
+var unwatchSelectFlow = &conversation.Flow[unwatchSelectData]{
+	Command: unwatchSelectCommand,
+	First:   1,
+	Steps: map[int16]conversation.Step[unwatchSelectData]{
+		1: {Handle: unwatchSelectStepStart},
+		2: {Handle: unwatchSelectStepToggle},
+	},
+}
+
+// UnwatchSelect lets the user tick several products of the watch list and remove them at once.
+// "/unwatch all" starts with every product ticked.
+func UnwatchSelect(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return unwatchSelectFlow.Run(ctx, req)
+}
+
+// unwatchSelectStepStart shows the first page, nothing is ticked when coming from /unwatch
+func unwatchSelectStepStart(ctx context.Context, req types.TelegramUpdate, state *unwatchSelectData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	state.Selected = []int32{}
+	if req.CallbackQuery.Id != "" {
+		// Answer callback query
+		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+	} else {
+		for _, item := range watchList {
+			state.Selected = append(state.Selected, item.ProductID)
+		}
+	}
+
+	return unwatchSelectPage(ctx, req, state)
+}
+
+// unwatchSelectStepToggle updates the selection, or removes the ticked products
+func unwatchSelectStepToggle(ctx context.Context, req types.TelegramUpdate, state *unwatchSelectData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	// It must be callback query
+	if req.CallbackQuery.Data == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// Answer callback query
+	err := service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if req.CallbackQuery.Data == "cancel" {
+		text, err := message.Render(ctx, "canceled", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	switch {
+	// Tick or untick a product
+	case strings.HasPrefix(req.CallbackQuery.Data, "toggle_"):
+		productId64, err := strconv.ParseInt(strings.TrimPrefix(req.CallbackQuery.Data, "toggle_"), 10, 32)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		productId := int32(productId64)
+
+		if i := slices.Index(state.Selected, productId); i >= 0 {
+			state.Selected = slices.Delete(state.Selected, i, i+1)
+		} else {
+			state.Selected = append(state.Selected, productId)
+		}
+
+	// Go to another page
+	case strings.HasPrefix(req.CallbackQuery.Data, "page_"):
+		page, err := strconv.Atoi(strings.TrimPrefix(req.CallbackQuery.Data, "page_"))
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		state.Page = max(page, 0)
+
+	// Remove the ticked products
+	case req.CallbackQuery.Data == "confirm":
+		if len(state.Selected) == 0 {
+			break
+		}
+
+		// Delete watch lists
+		err := database.Sqlc.DeleteWatchLists(ctx, &database.DeleteWatchListsParams{
+			ChatID:     chatId,
+			ProductIds: state.Selected,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "unwatch_select_removed", len(state.Selected))
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	return unwatchSelectPage(ctx, req, state)
+}
+
+// unwatchSelectPage shows a page of the watch list with checkboxes.
+// It edits the message if the user pressed a button, and sends a new one otherwise.
+func unwatchSelectPage(ctx context.Context, req types.TelegramUpdate, data *unwatchSelectData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	watchList, err := database.Sqlc.GetWatchList(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if len(watchList) == 0 {
+		text, err := message.Render(ctx, "watch_list_header", 0)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	pages := (len(watchList) + unwatchSelectPageSize - 1) / unwatchSelectPageSize
+	data.Page = min(data.Page, pages-1)
+
+	start := data.Page * unwatchSelectPageSize
+	end := min(start+unwatchSelectPageSize, len(watchList))
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, end-start+3) // +3 for navigation, confirm and cancel buttons
+	for _, item := range watchList[start:end] {
+		checkbox := "⬜"
+		if slices.Contains(data.Selected, item.ProductID) {
+			checkbox = "✅"
+		}
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         checkbox + " " + item.ProductLabel,
+				CallbackData: fmt.Sprintf("toggle_%d", item.ProductID),
+			},
+		})
+	}
+
+	var navigation []types.TelegramInlineKeyboardButton
+	if data.Page > 0 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_prev", nil),
+			CallbackData: fmt.Sprintf("page_%d", data.Page-1),
+		})
+	}
+	if data.Page < pages-1 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_next", nil),
+			CallbackData: fmt.Sprintf("page_%d", data.Page+1),
+		})
+	}
+	if len(navigation) > 0 {
+		inlineKeyboard = append(inlineKeyboard, navigation)
+	}
+
+	inlineKeyboard = append(inlineKeyboard,
+		[]types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_remove_selected", len(data.Selected)),
+				CallbackData: "confirm",
+			},
+		},
+		[]types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_cancel", nil),
+				CallbackData: "cancel",
+			},
+		},
+	)
+
+	text, err := message.Render(ctx, "unwatch_select", struct {
+		Page  int
+		Pages int
+	}{
+		Page:  data.Page + 1,
+		Pages: pages,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if req.CallbackQuery.Id == "" {
+		return conversation.Next(2, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+				InlineKeyboard: inlineKeyboard,
+			},
+		}), nil
+	}
+
+	return conversation.Next(2, &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	}), nil
+}
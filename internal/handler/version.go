@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Number of release cycles shown when no cycle is given
+const versionCyclesLimit = 10
+
+// Version replies to "/version <product> [cycle]" with the latest versions of a product
+func Version(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	reply := func(text string) *types.TelegramResponse {
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+			LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+				IsDisabled: true,
+			},
+		}
+	}
+
+	args := strings.Fields(req.Message.Text)[1:]
+	if len(args) == 0 {
+		text, err := message.Render(ctx, "version_usage", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+	keyword := args[0]
+	cycle := strings.Join(args[1:], " ")
+
+	// Best match
+	products, err := database.Sqlc.SearchProducts(ctx, &database.SearchProductsParams{
+		Keyword:    keyword,
+		PageSize:   1,
+		PageOffset: 0,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	if len(products) == 0 {
+		text, err := message.Render(ctx, "version_product_not_found", keyword)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return reply(text), nil
+	}
+	product := products[0]
+
+	detail, err := service.GetCachedEndOfLifeProduct(ctx, product.ApiUrl)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	item := productInfo{
+		Label: product.Label,
+		Url:   product.EolUrl,
+	}
+	if cycle == "" {
+		item.Cycles = productCycles(detail.Releases, versionCyclesLimit)
+	} else {
+		for _, c := range productCycles(detail.Releases, len(detail.Releases)) {
+			if strings.EqualFold(c.Name, cycle) || strings.EqualFold(c.Label, cycle) {
+				item.Cycles = append(item.Cycles, c)
+				break
+			}
+		}
+
+		if len(item.Cycles) == 0 {
+			cycles := productCycles(detail.Releases, versionCyclesLimit)
+			names := make([]string, len(cycles))
+			for i, c := range cycles {
+				names[i] = c.Name
+			}
+
+			text, err := message.Render(ctx, "version_cycle_not_found", struct {
+				Product string
+				Cycle   string
+				Cycles  []string
+			}{
+				Product: product.Label,
+				Cycle:   cycle,
+				Cycles:  names,
+			})
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+			return reply(text), nil
+		}
+	}
+
+	text, err := message.Render(ctx, "version", item)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	return reply(text), nil
+}
@@ -2,327 +2,532 @@ package handler
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fidrasofyan/version-watcher-bot/database"
-	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const command = "watch"
+const (
+	command = "watch"
+
+	// Number of products per page of search results
+	searchPageSize = 8
+
+	// Every message is taken as a keyword while waiting for one, so don't wait long
+	watchKeywordTimeout = 10 * time.Minute
+)
+
+// watchSearchData is either a search by keyword or a category being browsed
+type watchSearchData struct {
+	Keyword  string `json:"keyword,omitempty"`
+	Category string `json:"category,omitempty"`
+	Page     int    `json:"page"`
+}
+
+var watchFlow = &conversation.Flow[watchSearchData]{
+	Command: command,
+	First:   1,
+	Steps: map[int16]conversation.Step[watchSearchData]{
+		1: {Handle: watchStepPrompt},
+		2: {Timeout: watchKeywordTimeout, Handle: watchStepKeyword},
+		3: {Handle: watchStepProduct},
+	},
+}
 
 func Watch(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
-	var chatId int64
+	return watchFlow.Run(ctx, req)
+}
 
-	// Is it callback query?
-	if req.CallbackQuery.Data != "" {
-		chatId = req.CallbackQuery.From.Id
-	} else {
-		chatId = req.Message.Chat.Id
+// watchStepPrompt asks for a keyword
+func watchStepPrompt(ctx context.Context, req types.TelegramUpdate, _ *watchSearchData) (conversation.Transition, error) {
+	text, err := message.Render(ctx, "watch_prompt", nil)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
 	}
 
-	// Get chat
-	chat, err := repository.TelegramGetChat(ctx, chatId)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return nil, utils.NewError(err)
+	resp, err := buttonsPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    req.ChatId(),
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
+				{
+					{
+						Text:         message.Text(ctx, "button_browse_categories", nil),
+						CallbackData: "browse",
+					},
+				},
+				{
+					{
+						Text:         message.Text(ctx, "button_cancel", nil),
+						CallbackData: "cancel",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
 	}
 
-	if chat == nil {
-		// Create new chat
-		chat, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: command,
-			Step:    1,
-		})
+	return conversation.Next(2, resp), nil
+}
+
+// watchStepKeyword searches the products matching the keyword, or browses the categories
+func watchStepKeyword(ctx context.Context, req types.TelegramUpdate, state *watchSearchData) (conversation.Transition, error) {
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
+
+	// Browse by category
+	if req.CallbackQuery.Data == "browse" {
+		return watchEditCategories(ctx, req, state)
+	}
+
+	if strings.HasPrefix(req.CallbackQuery.Data, "category_") {
+		*state = watchSearchData{
+			Category: strings.TrimPrefix(req.CallbackQuery.Data, "category_"),
+		}
+		return watchEditSearchPage(ctx, req, state)
+	}
+
+	if len(req.Message.Text) < 2 {
+		text, err := message.Render(ctx, "watch_keyword_too_short", nil)
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, utils.NewError(err)
 		}
+
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    req.ChatId(),
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
 	}
 
-	switch chat.Step {
-	// Step 1
-	case 1:
-		// Set step
-		_, err := repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: command,
-			Step:    2,
-		})
+	return watchSearch(ctx, req, state, strings.TrimSpace(req.Message.Text))
+}
+
+// watchStepProduct adds the chosen product to the watch list, or goes to another page
+func watchStepProduct(ctx context.Context, req types.TelegramUpdate, state *watchSearchData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	// It must be callback query
+	if req.CallbackQuery.Data == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, utils.NewError(err)
 		}
 
-		return &types.TelegramResponse{
+		return conversation.End(&types.TelegramResponse{
 			Method:    types.TelegramMethodSendMessage,
 			ChatId:    chatId,
 			ParseMode: types.TelegramParseModeHTML,
-			Text: strings.Join([]string{
-				"What do you want to watch?",
-				"\n<i>E.g. Ubuntu, Nginx</i>",
-			}, "\n"),
-			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-				InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
-					{
-						{
-							Text:         "❌ Cancel",
-							CallbackData: "cancel",
-						},
-					},
-				},
-			},
-		}, nil
-
-	// Step 2
-	case 2:
-		if req.CallbackQuery.Data == "cancel" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			// Answer callback query
-			err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
-			})
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Canceled</i>",
-			}, nil
-		}
+			Text:      text,
+		}), nil
+	}
 
-		if len(req.Message.Text) < 2 {
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Keyword must be at least 2 characters</i>",
-				ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-					InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
-						{
-							{
-								Text:         "❌ Cancel",
-								CallbackData: "cancel",
-							},
-						},
-					},
-				},
-			}, nil
-		}
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
 
-		products, err := database.Sqlc.GetProductsByLabel(ctx, fmt.Sprint("%", req.Message.Text, "%"))
+	// Go to another page of the results
+	if strings.HasPrefix(req.CallbackQuery.Data, "page_") {
+		page, err := strconv.Atoi(strings.TrimPrefix(req.CallbackQuery.Data, "page_"))
 		if err != nil {
-			return nil, utils.NewError(err)
+			return conversation.Transition{}, utils.NewError(err)
 		}
+		state.Page = max(page, 0)
 
-		if len(products) == 0 {
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>No products found. Type another keyword...</i>",
-				ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-					InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
-						{
-							{
-								Text:         "❌ Cancel",
-								CallbackData: "cancel",
-							},
-						},
-					},
-				},
-			}, nil
-		}
+		return watchEditSearchPage(ctx, req, state)
+	}
 
-		inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, len(products)+1) // +1 for cancel button
+	// Back to categories
+	if req.CallbackQuery.Data == "browse" {
+		return watchEditCategories(ctx, req, state)
+	}
 
-		for i, product := range products {
-			inlineKeyboard[i] = []types.TelegramInlineKeyboardButton{
-				{
-					Text:         product.Label,
-					CallbackData: fmt.Sprint(product.ID),
-				},
-			}
-		}
+	productId64, err := strconv.ParseInt(req.CallbackQuery.Data, 10, 32)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	productId := int32(productId64)
+
+	product, err := database.Sqlc.GetProductById(ctx, productId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	// Add to watch list, nothing is added if it's already there
+	rows, err := database.Sqlc.CreateWatchList(ctx, &database.CreateWatchListParams{
+		ChatID:    chatId,
+		ProductID: productId,
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
 
-		inlineKeyboard[len(products)] = []types.TelegramInlineKeyboardButton{
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	template := "watch_added"
+	if rows == 0 {
+		template = "watch_already_watched"
+	}
+	text, err := message.Render(ctx, template, product.Label)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}), nil
+}
+
+// cancelConversation ends the session from the cancel button
+func cancelConversation(ctx context.Context, req types.TelegramUpdate) (conversation.Transition, error) {
+	// Answer callback query
+	err := service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "canceled", nil)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    req.ChatId(),
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}), nil
+}
+
+// cancelReplyMarkup is a single cancel button
+func cancelReplyMarkup(ctx context.Context) types.TelegramInlineKeyboardMarkup {
+	return types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: [][]types.TelegramInlineKeyboardButton{
 			{
-				Text: "❌ Cancel", CallbackData: "cancel",
+				{
+					Text:         message.Text(ctx, "button_cancel", nil),
+					CallbackData: "cancel",
+				},
 			},
-		}
+		},
+	}
+}
 
-		// Set step
-		_, err = repository.TelegramSetChat(ctx, &repository.TelegramSetChatParams{
-			ID:      chatId,
-			Command: command,
-			Step:    3,
-		})
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+// textPrompt makes the message wait for text. In a group, where the text only reaches the bot
+// as a reply to its message, the member who sent the update is asked to reply instead of
+// showing the cancel button, /cancel still works.
+func textPrompt(ctx context.Context, req types.TelegramUpdate, resp *types.TelegramResponse) *types.TelegramResponse {
+	if !req.IsGroupChat() {
+		resp.ReplyMarkup = cancelReplyMarkup(ctx)
+		return resp
+	}
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodSendMessage,
-			ChatId:    chatId,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      "Choose product:",
-			ReplyMarkup: types.TelegramInlineKeyboardMarkup{
-				InlineKeyboard: inlineKeyboard,
-			},
-		}, nil
-
-	// Step 3
-	case 3:
-		// It must be callback query
-		if req.CallbackQuery.Data == "" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodSendMessage,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Invalid command</i>",
-			}, nil
+	resp.ReplyMarkup = types.TelegramForceReply{
+		ForceReply: true,
+		Selective:  true,
+	}
+	if req.Message.MessageId != 0 {
+		resp.ReplyParameters = &types.TelegramReplyParameters{
+			MessageId:                req.Message.MessageId,
+			AllowSendingWithoutReply: true,
 		}
+	}
+	return resp
+}
+
+// buttonsPrompt is textPrompt for a message with other buttons, which can't be combined with a
+// forced reply. In a group, the message is sent as is and followed by a reply prompt.
+func buttonsPrompt(ctx context.Context, req types.TelegramUpdate, resp *types.TelegramResponse) (*types.TelegramResponse, error) {
+	if !req.IsGroupChat() {
+		return resp, nil
+	}
 
-		if req.CallbackQuery.Data == "cancel" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
+	err := service.SendResponse(ctx, resp)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
 
-			// Answer callback query
-			err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
-			})
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      "<i>Canceled</i>",
-			}, nil
-		}
+	text, err := message.Render(ctx, "reply_prompt", nil)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
 
-		productId64, err := strconv.ParseInt(req.CallbackQuery.Data, 10, 32)
-		if err != nil {
-			return nil, utils.NewError(err)
+	return textPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    req.ChatId(),
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}), nil
+}
+
+// watchEditCategories replaces the message with the list of product categories and goes back
+// to step 2, where a keyword can still be typed
+func watchEditCategories(ctx context.Context, req types.TelegramUpdate, state *watchSearchData) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	categories, err := database.Sqlc.GetProductCategories(ctx)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, (len(categories)+1)/2+1) // +1 for cancel button
+	for _, category := range categories {
+		// Callback data is limited to 64 bytes
+		if len(category.Category) > 64-len("category_") {
+			continue
 		}
-		productId := int32(productId64)
 
-		product, err := database.Sqlc.GetProductById(ctx, productId)
-		if err != nil {
-			return nil, utils.NewError(err)
+		button := types.TelegramInlineKeyboardButton{
+			Text:         fmt.Sprintf("%s (%d)", category.Category, category.Total),
+			CallbackData: "category_" + category.Category,
+		}
+		// Two categories per row
+		if n := len(inlineKeyboard); n > 0 && len(inlineKeyboard[n-1]) == 1 {
+			inlineKeyboard[n-1] = append(inlineKeyboard[n-1], button)
+			continue
 		}
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{button})
+	}
+	inlineKeyboard = append(inlineKeyboard, cancelReplyMarkup(ctx).InlineKeyboard...)
+
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "watch_choose_category", nil)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	*state = watchSearchData{}
+	return conversation.Next(2, &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+	}), nil
+}
+
+// watchEditSearchPage replaces the message with a page of the results.
+// A category without products goes back to the list of categories.
+func watchEditSearchPage(ctx context.Context, req types.TelegramUpdate, state *watchSearchData) (conversation.Transition, error) {
+	text, replyMarkup, err := watchSearchPage(ctx, state)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	if replyMarkup == nil && state.Category != "" {
+		return watchEditCategories(ctx, req, state)
+	}
 
-		// Is it already in watch list?
-		isWatchListExists, err := database.Sqlc.IsWatchListExists(ctx, &database.IsWatchListExistsParams{
-			ChatID:    chatId,
-			ProductID: productId,
+	// Answer callback query
+	err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	resp := &types.TelegramResponse{
+		Method:    types.TelegramMethodEditMessageText,
+		MessageId: req.CallbackQuery.Message.MessageId,
+		ChatId:    req.ChatId(),
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+	}
+	if replyMarkup != nil {
+		resp.ReplyMarkup = replyMarkup
+	}
+	return conversation.Next(3, resp), nil
+}
+
+// watchSearchPage renders a page of the products matching the keyword, ranked by similarity,
+// or of the products in the category. The page is moved back to the first one if it's past
+// the end. The reply markup is nil if no product matches.
+func watchSearchPage(ctx context.Context, data *watchSearchData) (string, *types.TelegramInlineKeyboardMarkup, error) {
+	var (
+		buttons []types.TelegramInlineKeyboardButton
+		total   int
+	)
+
+	if data.Category != "" {
+		products, err := database.Sqlc.GetProductsByCategory(ctx, &database.GetProductsByCategoryParams{
+			Category:   data.Category,
+			PageSize:   searchPageSize,
+			PageOffset: int32(data.Page * searchPageSize),
 		})
 		if err != nil {
-			return nil, utils.NewError(err)
+			return "", nil, utils.NewError(err)
 		}
-
-		if isWatchListExists {
-			// Delete chat
-			err = repository.TelegramDeleteChat(ctx, chatId)
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			// Answer callback query
-			err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
-				CallbackQueryId: req.CallbackQuery.Id,
+		for _, product := range products {
+			buttons = append(buttons, types.TelegramInlineKeyboardButton{
+				Text:         product.Label,
+				CallbackData: fmt.Sprint(product.ID),
 			})
-			if err != nil {
-				return nil, utils.NewError(err)
-			}
-
-			return &types.TelegramResponse{
-				Method:    types.TelegramMethodEditMessageText,
-				MessageId: req.CallbackQuery.Message.MessageId,
-				ChatId:    chatId,
-				ParseMode: types.TelegramParseModeHTML,
-				Text:      fmt.Sprintf("<i>❌ %s is already in watch list</i>", product.Label),
-			}, nil
+			total = int(product.Total)
 		}
-
-		// Add to watch list
-		_, err = database.Sqlc.CreateWatchList(ctx, &database.CreateWatchListParams{
-			ChatID:    chatId,
-			ProductID: productId,
-			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	} else {
+		products, err := database.Sqlc.SearchProducts(ctx, &database.SearchProductsParams{
+			Keyword:    data.Keyword,
+			PageSize:   searchPageSize,
+			PageOffset: int32(data.Page * searchPageSize),
 		})
 		if err != nil {
-			return nil, utils.NewError(err)
+			return "", nil, utils.NewError(err)
 		}
+		for _, product := range products {
+			buttons = append(buttons, types.TelegramInlineKeyboardButton{
+				Text:         product.Label,
+				CallbackData: fmt.Sprint(product.ID),
+			})
+			total = int(product.Total)
+		}
+	}
+
+	// Results may have changed since the previous page
+	if len(buttons) == 0 && data.Page > 0 {
+		data.Page = 0
+		return watchSearchPage(ctx, data)
+	}
 
-		// Delete chat
-		err = repository.TelegramDeleteChat(ctx, chatId)
+	if len(buttons) == 0 {
+		text, err := message.Render(ctx, "watch_no_products", nil)
 		if err != nil {
-			return nil, utils.NewError(err)
+			return "", nil, utils.NewError(err)
 		}
+		return text, nil, nil
+	}
+
+	pages := (total + searchPageSize - 1) / searchPageSize
 
-		// Answer callback query
-		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
-			CallbackQueryId: req.CallbackQuery.Id,
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, len(buttons)+3) // +3 for navigation, categories and cancel buttons
+	for _, button := range buttons {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{button})
+	}
+
+	var navigation []types.TelegramInlineKeyboardButton
+	if data.Page > 0 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_prev", nil),
+			CallbackData: fmt.Sprintf("page_%d", data.Page-1),
 		})
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+	}
+	if data.Page < pages-1 {
+		navigation = append(navigation, types.TelegramInlineKeyboardButton{
+			Text:         message.Text(ctx, "button_next", nil),
+			CallbackData: fmt.Sprintf("page_%d", data.Page+1),
+		})
+	}
+	if len(navigation) > 0 {
+		inlineKeyboard = append(inlineKeyboard, navigation)
+	}
 
-		var textB strings.Builder
-		textB.WriteString(fmt.Sprintf("✅ %s added to watch list\n\n", product.Label))
-		textB.WriteString("<i>*You'll be notified when a new version is released</i>")
+	if data.Category != "" {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_browse_categories", nil),
+				CallbackData: "browse",
+			},
+		})
+	}
 
-		return &types.TelegramResponse{
-			Method:    types.TelegramMethodEditMessageText,
-			MessageId: req.CallbackQuery.Message.MessageId,
-			ChatId:    chatId,
-			ParseMode: types.TelegramParseModeHTML,
-			Text:      textB.String(),
-		}, nil
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text:         message.Text(ctx, "button_cancel", nil),
+			CallbackData: "cancel",
+		},
+	})
+
+	text, err := message.Render(ctx, "watch_choose_product", struct {
+		Category string
+		Page     int
+		Pages    int
+		Total    int
+	}{
+		Category: data.Category,
+		Page:     data.Page + 1,
+		Pages:    pages,
+		Total:    total,
+	})
+	if err != nil {
+		return "", nil, utils.NewError(err)
+	}
 
-	// Unhandled step
-	default:
-		// Delete step
-		err := repository.TelegramDeleteChat(ctx, chatId)
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
+	return text, &types.TelegramInlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}, nil
+}
 
-		return &types.TelegramResponse{
-			Method:      types.TelegramMethodSendMessage,
-			ChatId:      req.Message.Chat.Id,
-			ParseMode:   types.TelegramParseModeHTML,
-			Text:        "<i>Unhandled step</i>",
-			ReplyMarkup: types.DefaultReplyMarkup,
-		}, nil
+// watchSearch shows the products matching the keyword and moves to step 3, where one is picked.
+// Without results, it stays in step 2 for another keyword.
+func watchSearch(ctx context.Context, req types.TelegramUpdate, state *watchSearchData, keyword string) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	*state = watchSearchData{
+		Keyword: keyword,
+	}
+	text, replyMarkup, err := watchSearchPage(ctx, state)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
 	}
 
+	if replyMarkup == nil {
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	return conversation.Next(3, &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: replyMarkup,
+	}), nil
+}
+
+// watchPrefill starts the watch flow as if the product name was typed, for "/start watch_<product>"
+func watchPrefill(ctx context.Context, req types.TelegramUpdate, product string) (*types.TelegramResponse, error) {
+	req.Message.Text = product
+	return watchFlow.RestartAt(ctx, req, 2)
 }
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// Maximum number of products watched at once
+	watchBulkLimit = 20
+	// Number of suggestions for an ambiguous name
+	watchBulkCandidates = 3
+)
+
+type watchBulkResult struct {
+	Added          []string
+	AlreadyWatched []string
+	Ambiguous      []watchBulkAmbiguous
+	NotFound       []string
+	Skipped        int
+	Limit          int
+}
+
+type watchBulkAmbiguous struct {
+	Keyword    string
+	Candidates []string
+}
+
+// WatchBulk adds the products of "/watch nginx, postgresql, redis" to the watch list.
+// Each name is matched against product names and labels first, then fuzzily.
+func WatchBulk(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	chatId := req.ChatId()
+
+	// Drop the command
+	_, args, _ := strings.Cut(req.Message.Text, " ")
+	keywords := splitProductNames(args)
+
+	result := watchBulkResult{
+		Limit: watchBulkLimit,
+	}
+	if len(keywords) > watchBulkLimit {
+		result.Skipped = len(keywords) - watchBulkLimit
+		keywords = keywords[:watchBulkLimit]
+	}
+
+	seen := make(map[int32]bool)
+	for _, keyword := range keywords {
+		match, err := matchProduct(ctx, keyword)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		switch {
+		case match == nil:
+			result.NotFound = append(result.NotFound, keyword)
+			continue
+		case len(match.Candidates) > 0:
+			result.Ambiguous = append(result.Ambiguous, watchBulkAmbiguous{
+				Keyword:    keyword,
+				Candidates: match.Candidates,
+			})
+			continue
+		}
+		productId := match.ID
+		productLabel := match.Label
+
+		// The same product may be named twice
+		if seen[productId] {
+			continue
+		}
+		seen[productId] = true
+
+		// Add to watch list, nothing is added if it's already there
+		rows, err := database.Sqlc.CreateWatchList(ctx, &database.CreateWatchListParams{
+			ChatID:    chatId,
+			ProductID: productId,
+			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if rows == 0 {
+			result.AlreadyWatched = append(result.AlreadyWatched, productLabel)
+			continue
+		}
+		result.Added = append(result.Added, productLabel)
+	}
+
+	text, err := message.Render(ctx, "watch_bulk_result", result)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}, nil
+}
+
+// splitProductNames splits "nginx, postgresql" or one name per line into product names
+func splitProductNames(text string) []string {
+	var names []string
+	for _, name := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// productMatch is the product a name refers to. If the name is ambiguous,
+// only Candidates is set.
+type productMatch struct {
+	ID         int32
+	Name       string
+	Label      string
+	Candidates []string
+}
+
+// matchProduct matches a name against product names and labels first, then fuzzily.
+// It returns nil if no product matches.
+func matchProduct(ctx context.Context, keyword string) (*productMatch, error) {
+	product, err := database.Sqlc.GetProductByNameOrLabel(ctx, keyword)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewError(err)
+	}
+	if err == nil {
+		return &productMatch{
+			ID:    product.ID,
+			Name:  product.Name,
+			Label: product.Label,
+		}, nil
+	}
+
+	products, err := database.Sqlc.SearchProducts(ctx, &database.SearchProductsParams{
+		Keyword:    keyword,
+		PageSize:   watchBulkCandidates,
+		PageOffset: 0,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &productMatch{
+			ID:    products[0].ID,
+			Name:  products[0].Name,
+			Label: products[0].Label,
+		}, nil
+	default:
+		match := productMatch{}
+		for _, p := range products {
+			match.Candidates = append(match.Candidates, p.Name)
+		}
+		return &match, nil
+	}
+}
+
+// matchWatchedProduct is matchProduct limited to the products the chat watches
+func matchWatchedProduct(ctx context.Context, chatId int64, keyword string) (*productMatch, error) {
+	product, err := database.Sqlc.GetWatchedProductByNameOrLabel(ctx, &database.GetWatchedProductByNameOrLabelParams{
+		ChatID:  chatId,
+		Keyword: keyword,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewError(err)
+	}
+	if err == nil {
+		return &productMatch{
+			ID:    product.ID,
+			Name:  product.Name,
+			Label: product.Label,
+		}, nil
+	}
+
+	products, err := database.Sqlc.SearchWatchedProducts(ctx, &database.SearchWatchedProductsParams{
+		ChatID:   chatId,
+		Keyword:  keyword,
+		PageSize: watchBulkCandidates,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &productMatch{
+			ID:    products[0].ID,
+			Name:  products[0].Name,
+			Label: products[0].Label,
+		}, nil
+	default:
+		match := productMatch{}
+		for _, p := range products {
+			match.Candidates = append(match.Candidates, p.Name)
+		}
+		return &match, nil
+	}
+}
@@ -2,11 +2,12 @@ package handler
 
 import (
 	"context"
-	"fmt"
-	"strings"
+	"log"
 
 	"github.com/bytedance/sonic"
 	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/running"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
@@ -20,29 +21,27 @@ type productVersion struct {
 	VersionReleaseLink *string          `json:"version_release_link"`
 }
 
+type watchListProduct struct {
+	Label    string
+	Url      string
+	Versions []watchListVersion
+	// Set if the chat recorded the version it runs
+	Running *running.Status
+}
+
+type watchListVersion struct {
+	Version string
+	Date    string
+}
+
 func WatchList(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
 	watchLists, err := database.Sqlc.GetWatchListsWithProductVersions(ctx, req.Message.Chat.Id)
 	if err != nil {
 		return nil, utils.NewError(err)
 	}
 
-	textLimit := 3500
-	var textB strings.Builder
-	textB.WriteString("<b>Watch List</b>\n")
-
-	switch len(watchLists) {
-	case 0:
-		textB.WriteString("\n<i>No watch list found</i>")
-	case 1:
-		textB.WriteString("<i>You watch 1 product</i>\n\n")
-	default:
-		textB.WriteString(fmt.Sprintf("<i>You watch %d products</i>\n\n", len(watchLists)))
-	}
-
-	for _, watchList := range watchLists {
-		// Set title
-		textB.WriteString(fmt.Sprintf("# <b>%s</b> - <a href=\"%s\">source</a>\n", watchList.ProductLabel, watchList.ProductEolUrl))
-
+	items := make([]watchListProduct, len(watchLists))
+	for i, watchList := range watchLists {
 		// Set product versions
 		productVersions := []productVersion{}
 		if len(watchList.ProductVersions) != 0 {
@@ -51,38 +50,71 @@ func WatchList(ctx context.Context, req types.TelegramUpdate) (*types.TelegramRe
 			}
 		}
 
-		for _, pv := range productVersions {
-			if pv.VersionReleaseDate.Valid {
-				textB.WriteString(fmt.Sprintf("• Latest: %s - %s\n", pv.Version, pv.VersionReleaseDate.Time.Format("2 Jan 2006")))
-			} else {
-				textB.WriteString("• Latest release: -\n")
-			}
+		items[i] = watchListProduct{
+			Label:    watchList.ProductLabel,
+			Url:      watchList.ProductEolUrl,
+			Versions: make([]watchListVersion, len(productVersions)),
+		}
+		for j, pv := range productVersions {
+			items[i].Versions[j].Version = pv.Version
+			items[i].Versions[j].Date = formatTimestamp(pv.VersionReleaseDate)
 		}
 
-		// If text is too long, send it part by part
-		if textB.Len() >= textLimit {
-			service.SendMessage(ctx, &service.SendMessageParams{
-				ChatId:             req.Message.Chat.Id,
-				ParseMode:          service.TelegramParseModeHTML,
-				Text:               textB.String(),
-				LinkPreviewOptions: &types.TelegramLinkPreviewOptions{IsDisabled: true},
-			})
-			textB.Reset()
+		if watchList.RunningVersion != nil {
+			status, err := running.Check(ctx, watchList.ProductID, watchList.ProductApiUrl, *watchList.RunningVersion)
+			if err != nil {
+				// The watch list is still useful without it
+				log.Printf("WatchList: %s: %v", watchList.ProductLabel, err)
+				continue
+			}
+			items[i].Running = status
 		}
 	}
 
-	if textB.Len() == 0 {
-		return nil, nil
+	text, err := message.Render(ctx, "watch_list", items)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	// If text is too long, send it part by part
+	text, err = sendLeadingParts(ctx, req.Message.Chat.Id, text)
+	if err != nil {
+		return nil, utils.NewError(err)
 	}
 
 	return &types.TelegramResponse{
 		Method:      types.TelegramMethodSendMessage,
 		ChatId:      req.Message.Chat.Id,
 		ParseMode:   types.TelegramParseModeHTML,
-		Text:        textB.String(),
-		ReplyMarkup: types.DefaultReplyMarkup,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
 		LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
 			IsDisabled: true,
 		},
 	}, nil
 }
+
+// sendLeadingParts sends all but the last part of a long message and returns
+// the last one, which is sent as the reply to the webhook request
+func sendLeadingParts(ctx context.Context, chatId int64, text string) (string, error) {
+	parts := message.Split(text)
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		err := service.SendMessage(ctx, &service.SendMessageParams{
+			ChatId:    chatId,
+			ParseMode: service.TelegramParseModeHTML,
+			Text:      part,
+			LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+				IsDisabled: true,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return parts[len(parts)-1], nil
+}
@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/conversation"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/notifier"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxWebhooks        = 5
+	webhookPingTimeout = 5 * time.Second
+)
+
+var webhookFlow = &conversation.Flow[struct{}]{
+	Command: "webhook",
+	First:   1,
+	Steps: map[int16]conversation.Step[struct{}]{
+		1: {Handle: webhookStepList},
+		2: {Handle: webhookStepRegister},
+	},
+}
+
+func Webhook(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	return webhookFlow.Run(ctx, req)
+}
+
+// webhookStepList lists the registered webhooks
+func webhookStepList(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	webhooks, err := database.Sqlc.GetWebhooksByChat(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "webhook_list", webhooks)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	inlineKeyboard := make([][]types.TelegramInlineKeyboardButton, 0, len(webhooks)+1) // +1 for cancel button
+	for i, webhook := range webhooks {
+		inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+			{
+				Text:         message.Text(ctx, "button_remove_webhook", i+1),
+				CallbackData: fmt.Sprintf("remove_%d", webhook.ID),
+			},
+		})
+	}
+	inlineKeyboard = append(inlineKeyboard, []types.TelegramInlineKeyboardButton{
+		{
+			Text:         message.Text(ctx, "button_cancel", nil),
+			CallbackData: "cancel",
+		},
+	})
+
+	resp, err := buttonsPrompt(ctx, req, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    chatId,
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		ReplyMarkup: types.TelegramInlineKeyboardMarkup{
+			InlineKeyboard: inlineKeyboard,
+		},
+		LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+			IsDisabled: true,
+		},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.Next(2, resp), nil
+}
+
+// webhookStepRegister registers the sent URL, or removes the chosen webhook
+func webhookStepRegister(ctx context.Context, req types.TelegramUpdate, _ *struct{}) (conversation.Transition, error) {
+	chatId := req.ChatId()
+
+	if req.CallbackQuery.Data == "cancel" {
+		return cancelConversation(ctx, req)
+	}
+
+	if strings.HasPrefix(req.CallbackQuery.Data, "remove_") {
+		webhookId, err := strconv.ParseInt(strings.TrimPrefix(req.CallbackQuery.Data, "remove_"), 10, 32)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Delete webhook
+		err = database.Sqlc.DeleteWebhook(ctx, &database.DeleteWebhookParams{
+			ID:     int32(webhookId),
+			ChatID: chatId,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		// Answer callback query
+		err = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		text, err := message.Render(ctx, "webhook_removed", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	// It must be text message
+	if req.Message.Text == "" {
+		text, err := message.Render(ctx, "invalid_command", nil)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}), nil
+	}
+
+	count, err := database.Sqlc.CountWebhooks(ctx, chatId)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+	if count >= maxWebhooks {
+		text, err := message.Render(ctx, "webhook_limit", maxWebhooks)
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.End(&types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      chatId,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}), nil
+	}
+
+	webhookUrl, webhookKind, reason := validateWebhookUrl(ctx, req.Message.Text)
+	if reason == "" {
+		exists, err := database.Sqlc.IsWebhookExists(ctx, &database.IsWebhookExistsParams{
+			ChatID: chatId,
+			Url:    webhookUrl,
+		})
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+		if exists {
+			reason = "reason_webhook_exists"
+		}
+	}
+	if reason != "" {
+		text, err := message.Render(ctx, "webhook_invalid", message.Text(ctx, reason, nil))
+		if err != nil {
+			return conversation.Transition{}, utils.NewError(err)
+		}
+
+		return conversation.Stay(textPrompt(ctx, req, &types.TelegramResponse{
+			Method:    types.TelegramMethodSendMessage,
+			ChatId:    chatId,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		})), nil
+	}
+
+	// Generate secret
+	secretB := make([]byte, 32)
+	if _, err := rand.Read(secretB); err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	webhook, err := database.Sqlc.CreateWebhook(ctx, &database.CreateWebhookParams{
+		ChatID:    chatId,
+		Kind:      webhookKind,
+		Url:       webhookUrl,
+		Secret:    hex.EncodeToString(secretB),
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	// Let the endpoint know it's registered, a slow endpoint doesn't hold up the reply for long
+	pingCtx, cancel := context.WithTimeout(ctx, webhookPingTimeout)
+	err = notifier.NewWebhookChannel(webhook).Ping(pingCtx)
+	cancel()
+	if err != nil {
+		log.Printf("Webhook: ping: %v", err)
+	}
+
+	secret, err := sendWebhookSecret(ctx, req, webhook)
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	text, err := message.Render(ctx, "webhook_registered", struct {
+		Kind            string
+		Secret          string
+		Group           bool
+		SignatureHeader string
+	}{
+		Kind:            webhook.Kind,
+		Secret:          secret,
+		Group:           !req.IsPrivateChat(),
+		SignatureHeader: notifier.WebhookSignatureHeader,
+	})
+	if err != nil {
+		return conversation.Transition{}, utils.NewError(err)
+	}
+
+	return conversation.End(&types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      chatId,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}), nil
+}
+
+// sendWebhookSecret sends the secret of a generic webhook registered in a group to the user in
+// private, every member can read the group. It returns the secret if it must be shown in the
+// chat instead: in a private chat, or if the user hasn't started the bot.
+func sendWebhookSecret(ctx context.Context, req types.TelegramUpdate, webhook *database.Webhook) (string, error) {
+	if webhook.Kind != notifier.WebhookKindGeneric {
+		return "", nil
+	}
+	if req.IsPrivateChat() {
+		return webhook.Secret, nil
+	}
+
+	text, err := message.Render(ctx, "webhook_secret", struct {
+		Url    string
+		Secret string
+	}{
+		Url:    webhook.Url,
+		Secret: webhook.Secret,
+	})
+	if err != nil {
+		return "", utils.NewError(err)
+	}
+
+	err = service.SendResponse(ctx, &types.TelegramResponse{
+		Method:    types.TelegramMethodSendMessage,
+		ChatId:    req.UserId(),
+		ParseMode: types.TelegramParseModeHTML,
+		Text:      text,
+		LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+			IsDisabled: true,
+		},
+	})
+	if err != nil {
+		var telegramErr *service.TelegramError
+		if errors.As(err, &telegramErr) {
+			// The bot can't start a private chat
+			return webhook.Secret, nil
+		}
+		return "", utils.NewError(err)
+	}
+
+	return "", nil
+}
+
+// validateWebhookUrl returns the normalized URL and its kind, or the name of the reason template
+// if it can't be used
+func validateWebhookUrl(ctx context.Context, rawUrl string) (string, string, string) {
+	rawUrl = strings.TrimSpace(rawUrl)
+	if len(rawUrl) > 2048 {
+		return "", "", "reason_url_too_long"
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return "", "", "reason_url_invalid"
+	}
+
+	// Plain HTTP is only allowed in development, e.g. for a local receiver
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if config.Cfg.AppEnv != "development" {
+			return "", "", "reason_url_not_https"
+		}
+	default:
+		return "", "", "reason_url_invalid"
+	}
+
+	// Requests to the internal network of the bot aren't allowed
+	err = notifier.ResolvePublicHost(ctx, u.Hostname())
+	if errors.Is(err, notifier.ErrPrivateAddress) {
+		return "", "", "reason_url_private"
+	}
+	if err != nil {
+		return "", "", "reason_url_unresolved"
+	}
+
+	return u.String(), notifier.DetectWebhookKind(u), ""
+}
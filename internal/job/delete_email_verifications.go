@@ -0,0 +1,29 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DeleteEmailVerifications forgets the confirmation codes sent more than a day ago, they no
+// longer count towards the limits
+func DeleteEmailVerifications(ctx context.Context) error {
+	rows, err := database.Sqlc.DeleteEmailVerifications(ctx, pgtype.Timestamp{
+		Time:  time.Now().Add(-24 * time.Hour),
+		Valid: true,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	if rows > 0 {
+		log.Printf("Deleted %d email verifications", rows)
+	}
+
+	return nil
+}
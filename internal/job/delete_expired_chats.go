@@ -0,0 +1,24 @@
+package job
+
+import (
+	"context"
+	"log"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// DeleteExpiredChats clears the conversation state of abandoned flows. Expired state is
+// already ignored, this only keeps the chats table small.
+func DeleteExpiredChats(ctx context.Context) error {
+	rows, err := repository.TelegramDeleteExpiredChats(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	if rows > 0 {
+		log.Printf("Deleted %d expired chats", rows)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package job
+
+import (
+	"context"
+	"log"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// DeleteProcessedUpdates forgets the updates Telegram no longer redelivers
+func DeleteProcessedUpdates(ctx context.Context) error {
+	rows, err := repository.TelegramDeleteProcessedUpdates(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	if rows > 0 {
+		log.Printf("Deleted %d processed updates", rows)
+	}
+
+	return nil
+}
@@ -3,18 +3,38 @@ package job
 import (
 	"context"
 	"fmt"
+	"log"
 	"slices"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/notifier"
+	"github.com/fidrasofyan/version-watcher-bot/internal/running"
 	"github.com/fidrasofyan/version-watcher-bot/internal/service"
-	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const (
+	webhookDeliveryRetentionDays = 30
+	// Webhooks of a run are delivered by this many workers, until the deadline
+	webhookWorkers     = 8
+	webhookRunDeadline = 5 * time.Minute
+)
+
+// webhookDelivery is a webhook waiting for the releases of its chat
+type webhookDelivery struct {
+	chatId   int64
+	locale   string
+	channel  notifier.Channel
+	releases []*notifier.Release
+}
+
 type productVersion struct {
+	ReleaseName        string           `json:"release_name"`
 	ReleaseLabel       string           `json:"release_label"`
 	Version            string           `json:"version"`
 	VersionReleaseDate pgtype.Timestamp `json:"version_release_date"`
@@ -23,6 +43,7 @@ type productVersion struct {
 
 type product struct {
 	ProductId       int32
+	ProductName     string
 	ProductLabel    string
 	ProductEolUrl   string
 	ProductVersions []productVersion
@@ -62,6 +83,7 @@ func NotifyUsers(ctx context.Context) error {
 		}
 		products[i] = product{
 			ProductId:       p.ProductID,
+			ProductName:     p.ProductName,
 			ProductLabel:    p.ProductLabel,
 			ProductEolUrl:   p.ProductEolUrl,
 			ProductVersions: productVersions,
@@ -74,7 +96,64 @@ func NotifyUsers(ctx context.Context) error {
 		return utils.NewError(err)
 	}
 
-	// Notify users
+	// Get linked channels
+	watchListChannels, err := database.Sqlc.GetWatchListChannels(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	channelIds := make(map[int64][]int64, len(watchListChannels))
+	for _, wlc := range watchListChannels {
+		channelIds[wlc.ChatID] = append(channelIds[wlc.ChatID], wlc.ChannelID)
+	}
+
+	// Get webhooks
+	webhooks, err := database.Sqlc.GetWebhooks(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	webhooksByChat := make(map[int64][]*database.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		webhooksByChat[webhook.ChatID] = append(webhooksByChat[webhook.ChatID], webhook)
+	}
+
+	// Get verified email subscriptions
+	emailSubscriptionsByChat := make(map[int64][]*database.EmailSubscription)
+	if service.IsEmailEnabled() {
+		emailSubscriptions, err := database.Sqlc.GetVerifiedEmailSubscriptions(ctx)
+		if err != nil {
+			return utils.NewError(err)
+		}
+		for _, subscription := range emailSubscriptions {
+			emailSubscriptionsByChat[subscription.ChatID] = append(emailSubscriptionsByChat[subscription.ChatID], subscription)
+		}
+	}
+
+	// Get languages, private chats share the id of the user
+	userLanguages, err := database.Sqlc.GetUserLanguages(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	locales := make(map[int64]string, len(userLanguages))
+	for _, ul := range userLanguages {
+		if locale := message.ResolveLocale(ul.Language); locale != "" {
+			locales[ul.ID] = locale
+		}
+	}
+
+	// Get running versions
+	runningVersions, err := database.Sqlc.GetRunningVersions(ctx)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	runningVersionsByChat := make(map[int64][]*database.GetRunningVersionsRow, len(runningVersions))
+	for _, rv := range runningVersions {
+		runningVersionsByChat[rv.ChatID] = append(runningVersionsByChat[rv.ChatID], rv)
+	}
+	// Chats running the same version share the status
+	runningStatuses := make(map[string]*running.Status)
+
+	// Notify users, webhooks are delivered afterwards so a slow endpoint doesn't hold up the chats
+	var webhookDeliveries []webhookDelivery
 	for _, wl := range watchLists {
 		var productIds []int32
 		if err := sonic.Unmarshal(wl.ProductIds, &productIds); err != nil {
@@ -86,66 +165,140 @@ func NotifyUsers(ctx context.Context) error {
 			continue
 		}
 
-		// Send notification
-		textLimit := 3500
-		var textB strings.Builder
-		if len(filteredProducts) > 1 {
-			textB.WriteString("<b>New Releases Detected</b>\n\n")
-		} else {
-			textB.WriteString("<b>New Release Detected</b>\n\n")
+		releases := toReleases(filteredProducts)
+		setRunningStatuses(ctx, releases, filteredProducts, runningVersionsByChat[wl.ChatID], runningStatuses)
+
+		// Fan out to the chat, its linked channels, webhooks and email addresses
+		channels := []notifier.Channel{
+			&notifier.Telegram{ChatId: wl.ChatID},
+		}
+		for _, channelId := range channelIds[wl.ChatID] {
+			channels = append(channels, &notifier.Telegram{ChatId: channelId})
+		}
+		for _, subscription := range emailSubscriptionsByChat[wl.ChatID] {
+			channels = append(channels, notifier.NewEmailChannel(subscription))
+		}
+		for _, webhook := range webhooksByChat[wl.ChatID] {
+			webhookDeliveries = append(webhookDeliveries, webhookDelivery{
+				chatId:   wl.ChatID,
+				locale:   locales[wl.ChatID],
+				channel:  notifier.NewWebhookChannel(webhook),
+				releases: releases,
+			})
+		}
+
+		chatCtx := ctx
+		if locale, ok := locales[wl.ChatID]; ok {
+			chatCtx = message.WithLocale(ctx, locale)
 		}
 
-		for _, p := range filteredProducts {
-			// Set title
-			textB.WriteString(fmt.Sprintf("# <b>%s</b> - <a href=\"%s\">source</a>\n", p.ProductLabel, p.ProductEolUrl))
+		for _, channel := range channels {
+			if err := channel.Notify(chatCtx, releases); err != nil {
+				log.Printf("Notify: chat %d: %v", wl.ChatID, err)
+			}
+		}
+	}
 
-			// Set product versions
-			for _, pv := range p.ProductVersions {
-				textB.WriteString(fmt.Sprintf("Version: <code>%s</code> | Label: %s\n", pv.Version, pv.ReleaseLabel))
+	deliverWebhooks(ctx, webhookDeliveries)
 
-				if pv.VersionReleaseDate.Valid {
-					textB.WriteString(fmt.Sprintf("• Release: %s\n", pv.VersionReleaseDate.Time.Format("2 Jan 2006")))
-				} else {
-					textB.WriteString("• Release: -\n")
-				}
+	// Clean up old webhook deliveries
+	err = database.Sqlc.DeleteWebhookDeliveriesBefore(ctx, pgtype.Timestamp{
+		Time:  time.Now().AddDate(0, 0, -webhookDeliveryRetentionDays),
+		Valid: true,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
 
-				if pv.VersionReleaseLink != nil && *pv.VersionReleaseLink != "" {
-					textB.WriteString(fmt.Sprintf("• Changelog: <a href=\"%s\">%s</a>\n", *pv.VersionReleaseLink, "link"))
-				} else {
-					textB.WriteString("• Changelog: -\n")
-				}
+	return nil
+}
+
+// deliverWebhooks delivers the webhooks concurrently. Those still waiting at the deadline of the
+// run are skipped, the next releases will reach them.
+func deliverWebhooks(ctx context.Context, deliveries []webhookDelivery) {
+	ctx, cancel := context.WithTimeout(ctx, webhookRunDeadline)
+	defer cancel()
+
+	slots := make(chan struct{}, webhookWorkers)
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			log.Printf("Notify: %d webhooks skipped: %v", len(deliveries)-i, ctx.Err())
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			deliveryCtx := ctx
+			if delivery.locale != "" {
+				deliveryCtx = message.WithLocale(ctx, delivery.locale)
 			}
-			textB.WriteString("\n")
-
-			// If text is too long, send it part by part
-			if textB.Len() >= textLimit {
-				service.SendMessage(ctx, &service.SendMessageParams{
-					ChatId:    wl.ChatID,
-					ParseMode: service.TelegramParseModeHTML,
-					Text:      textB.String(),
-					LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
-						IsDisabled: true,
-					},
-				})
-				textB.Reset()
+			if err := delivery.channel.Notify(deliveryCtx, delivery.releases); err != nil {
+				log.Printf("Notify: chat %d: %v", delivery.chatId, err)
 			}
-		}
+		}()
+	}
+	wg.Wait()
+}
 
-		if textB.Len() == 0 {
-			return nil
+func toReleases(products []product) []*notifier.Release {
+	var releases []*notifier.Release
+	for _, p := range products {
+		for _, pv := range p.ProductVersions {
+			release := &notifier.Release{
+				ProductName:  p.ProductName,
+				ProductLabel: p.ProductLabel,
+				ProductUrl:   p.ProductEolUrl,
+				Cycle:        pv.ReleaseName,
+				CycleLabel:   pv.ReleaseLabel,
+				Version:      pv.Version,
+				Link:         pv.VersionReleaseLink,
+			}
+			if pv.VersionReleaseDate.Valid {
+				date := pv.VersionReleaseDate.Time.Format("2006-01-02")
+				release.Date = &date
+			}
+			releases = append(releases, release)
 		}
+	}
+	return releases
+}
 
-		service.SendMessage(ctx, &service.SendMessageParams{
-			ChatId:    wl.ChatID,
-			ParseMode: service.TelegramParseModeHTML,
-			Text:      textB.String(),
-			LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
-				IsDisabled: true,
-			},
+// setRunningStatuses tells on the first release of each product how far the version the chat runs is behind
+func setRunningStatuses(ctx context.Context, releases []*notifier.Release, products []product, runningVersions []*database.GetRunningVersionsRow, statuses map[string]*running.Status) {
+	for _, rv := range runningVersions {
+		i := slices.IndexFunc(products, func(p product) bool {
+			return p.ProductId == rv.ProductID
 		})
-	}
+		if i < 0 {
+			continue
+		}
+		j := slices.IndexFunc(releases, func(r *notifier.Release) bool {
+			return r.ProductName == products[i].ProductName
+		})
+		if j < 0 {
+			continue
+		}
 
-	return nil
+		key := fmt.Sprintf("%d:%s", rv.ProductID, rv.RunningVersion)
+		status, ok := statuses[key]
+		if !ok {
+			var err error
+			status, err = running.Check(ctx, rv.ProductID, rv.ProductApiUrl, rv.RunningVersion)
+			if err != nil {
+				log.Printf("Notify: running status of %s: %v", products[i].ProductName, err)
+				continue
+			}
+			statuses[key] = status
+		}
+		releases[j].Running = status
+	}
 }
 
 func filterProducts(products []product, productIds []int32) []product {
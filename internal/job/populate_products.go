@@ -13,6 +13,7 @@ import (
 	"github.com/bytedance/sonic"
 	"github.com/fidrasofyan/version-watcher-bot/database"
 	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -31,39 +32,6 @@ type FetchProductsResponse struct {
 	Result        []Product `json:"result"`
 }
 
-type LatestRelease struct {
-	Name *string `json:"name"`
-	Date *string `json:"date"`
-	Link *string `json:"link"`
-}
-
-type Custom struct {
-	APIVersion *string `json:"apiVersion"`
-}
-
-type Release struct {
-	Name        string         `json:"name"`
-	Codename    *string        `json:"codename"`
-	Label       string         `json:"label"`
-	ReleaseDate *string        `json:"releaseDate"`
-	Latest      *LatestRelease `json:"latest"`
-	Custom      *Custom        `json:"custom"`
-}
-
-type FetchProductDetailResult struct {
-	Name     string    `json:"name"`
-	Label    string    `json:"label"`
-	Category string    `json:"category"`
-	Releases []Release `json:"releases"`
-}
-
-type FetchProductDetailResponse struct {
-	SchemaVersion string                   `json:"schema_version"`
-	GeneratedAt   string                   `json:"generated_at"`
-	LastModified  string                   `json:"last_modified"`
-	Result        FetchProductDetailResult `json:"result"`
-}
-
 var httpClient = &http.Client{
 	Transport: &http.Transport{
 		DialContext: (&net.Dialer{
@@ -168,28 +136,12 @@ func PopulateProducts(ctx context.Context) (*time.Time, error) {
 			log.Printf("Fetching product %s...", wp.Name)
 		}
 
-		fetchProductReq, err := http.NewRequestWithContext(
-			ctxWithTimeout,
-			"GET",
-			wp.ApiUrl.(string),
-			nil,
-		)
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
-
-		fetchProductRes, err := httpClient.Do(fetchProductReq)
+		pr, err := service.GetEndOfLifeProduct(ctxWithTimeout, wp.ApiUrl.(string))
 		if err != nil {
 			return nil, utils.NewError(err)
 		}
 
-		var pr FetchProductDetailResponse
-		if err := sonic.ConfigDefault.NewDecoder(fetchProductRes.Body).Decode(&pr); err != nil {
-			return nil, utils.NewError(err)
-		}
-		fetchProductRes.Body.Close()
-
-		for _, release := range pr.Result.Releases {
+		for _, release := range pr.Releases {
 			// Check context
 			select {
 			case <-ctxWithTimeout.Done():
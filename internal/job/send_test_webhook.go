@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	neturl "net/url"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/notifier"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// SendTestWebhook sends a sample release to an URL that isn't registered,
+// which is handy to check a receiver's signature verification or a Slack/Discord channel
+func SendTestWebhook(ctx context.Context, url string, secret string) error {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	link := "https://www.postgresql.org/docs/release/16.4/"
+
+	channel := notifier.NewWebhookChannel(&database.Webhook{
+		Kind:   notifier.DetectWebhookKind(u),
+		Url:    url,
+		Secret: secret,
+	})
+	err = channel.Notify(ctx, []*notifier.Release{{
+		ProductName:  "postgresql",
+		ProductLabel: "PostgreSQL",
+		ProductUrl:   "https://endoflife.date/postgresql",
+		Cycle:        "16",
+		CycleLabel:   "16",
+		Version:      "16.4",
+		Date:         &date,
+		Link:         &link,
+	}})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
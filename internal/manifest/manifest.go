@@ -0,0 +1,275 @@
+// Package manifest finds the products a project depends on in its dependency manifests
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+var ErrUnsupported = errors.New("unsupported manifest")
+
+// Kinds of manifests, as shown to users
+const (
+	KindGoMod         = "go.mod"
+	KindPackageJson   = "package.json"
+	KindRequirements  = "requirements.txt"
+	KindDockerfile    = "Dockerfile"
+	KindDockerCompose = "docker-compose.yml"
+	KindToolVersions  = ".tool-versions"
+)
+
+// Names of dependencies that differ from the names of the products on endoflife.date
+var aliases = map[string]string{
+	"golang":                      "go",
+	"node":                        "nodejs",
+	"@angular/core":               "angular",
+	"next":                        "nextjs",
+	"postgres":                    "postgresql",
+	"mongo":                       "mongodb",
+	"httpd":                       "apache-http-server",
+	"amazoncorretto":              "amazon-corretto",
+	"k8s":                         "kubernetes",
+	"elasticsearch/elasticsearch": "elasticsearch",
+	"bitnami/redis":               "redis",
+	"bitnami/postgresql":          "postgresql",
+	"bitnami/mysql":               "mysql",
+	"bitnami/mariadb":             "mariadb",
+	"bitnami/mongodb":             "mongodb",
+	"bitnami/rabbitmq":            "rabbitmq",
+}
+
+// Kind returns the kind of manifest a file is, judging by its name
+func Kind(fileName string) (string, error) {
+	name := strings.ToLower(path.Base(fileName))
+	switch {
+	case name == "go.mod":
+		return KindGoMod, nil
+	case name == "package.json":
+		return KindPackageJson, nil
+	case strings.HasSuffix(name, ".txt") && strings.Contains(name, "requirements"):
+		return KindRequirements, nil
+	case name == "dockerfile" || strings.HasPrefix(name, "dockerfile.") || strings.HasSuffix(name, ".dockerfile"):
+		return KindDockerfile, nil
+	case slices.Contains([]string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}, name):
+		return KindDockerCompose, nil
+	case name == ".tool-versions":
+		return KindToolVersions, nil
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// Parse returns the names of the products a manifest depends on, as they would be named on
+// endoflife.date. Names are lowercase and unique, but not necessarily known products.
+func Parse(fileName string, content []byte) ([]string, error) {
+	kind, err := Kind(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	switch kind {
+	case KindGoMod:
+		names = parseGoMod(content)
+	case KindPackageJson:
+		names, err = parsePackageJson(content)
+	case KindRequirements:
+		names = parseRequirements(content)
+	case KindDockerfile:
+		names = parseDockerfile(content)
+	case KindDockerCompose:
+		names = parseDockerCompose(content)
+	case KindToolVersions:
+		names = parseToolVersions(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var products []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		if name != "" && !slices.Contains(products, name) {
+			products = append(products, name)
+		}
+	}
+	return products, nil
+}
+
+// lines returns the lines of a file without comments and surrounding spaces
+func lines(content []byte, comment string) []string {
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), comment)
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// parseGoMod returns Go itself and the last element of the required module paths
+// (e.g. github.com/gin-gonic/gin -> gin)
+func parseGoMod(content []byte) []string {
+	var names []string
+	inRequire := false
+	for _, line := range lines(content, "//") {
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "go" || fields[0] == "toolchain":
+			names = append(names, "go")
+		case line == "require (":
+			inRequire = true
+		case line == ")":
+			inRequire = false
+		case fields[0] == "require" && len(fields) >= 2:
+			names = append(names, modulePathName(fields[1]))
+		case inRequire:
+			names = append(names, modulePathName(fields[0]))
+		}
+	}
+	return names
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v\d+$`)
+
+func modulePathName(modulePath string) string {
+	elements := strings.Split(modulePath, "/")
+	name := elements[len(elements)-1]
+	// e.g. github.com/jackc/pgx/v5
+	if majorVersionSuffix.MatchString(name) && len(elements) > 1 {
+		name = elements[len(elements)-2]
+	}
+	return name
+}
+
+// parsePackageJson returns Node.js and the dependencies of the package
+func parsePackageJson(content []byte) ([]string, error) {
+	var pkg struct {
+		Engines              map[string]string `json:"engines"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := sonic.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+
+	names := []string{"nodejs"}
+	for _, dependencies := range []map[string]string{
+		pkg.Engines,
+		pkg.Dependencies,
+		pkg.DevDependencies,
+		pkg.PeerDependencies,
+		pkg.OptionalDependencies,
+	} {
+		for name := range dependencies {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[1:])
+	return names, nil
+}
+
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// parseRequirements returns Python and the required packages
+func parseRequirements(content []byte) []string {
+	names := []string{"python"}
+	for _, line := range lines(content, "#") {
+		// Options such as -r other.txt or --index-url
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+		if name := requirementName.FindString(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseDockerfile returns the base images
+func parseDockerfile(content []byte) []string {
+	var names []string
+	var stages []string
+	for _, line := range lines(content, "#") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+
+		// Skip flags such as --platform
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		image := args[0]
+		// Later stages may be based on earlier ones
+		if !slices.Contains(stages, strings.ToLower(image)) {
+			names = append(names, imageName(image))
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages = append(stages, strings.ToLower(args[2]))
+		}
+	}
+	return names
+}
+
+var composeImage = regexp.MustCompile(`^image:\s*["']?([^"'\s]+)`)
+
+// parseDockerCompose returns the images of the services
+func parseDockerCompose(content []byte) []string {
+	var names []string
+	for _, line := range lines(content, " #") {
+		if match := composeImage.FindStringSubmatch(line); match != nil {
+			names = append(names, imageName(match[1]))
+		}
+	}
+	return names
+}
+
+// imageName strips the registry, tag and digest of an image (e.g. docker.io/library/nginx:1.27 -> nginx).
+// The namespace is kept for images outside of the official library (e.g. bitnami/redis).
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	elements := strings.Split(image, "/")
+	// The first element is a registry if it looks like a host
+	if len(elements) > 1 && (strings.ContainsAny(elements[0], ".:") || elements[0] == "localhost") {
+		elements = elements[1:]
+	}
+	if len(elements) > 1 && elements[0] == "library" {
+		elements = elements[1:]
+	}
+	name := strings.Join(elements, "/")
+	name, _, _ = strings.Cut(name, ":")
+	// Variables can't be resolved
+	if strings.Contains(name, "$") || name == "scratch" {
+		return ""
+	}
+	return name
+}
+
+// parseToolVersions returns the tools managed by asdf or mise
+func parseToolVersions(content []byte) []string {
+	var names []string
+	for _, line := range lines(content, "#") {
+		names = append(names, strings.Fields(line)[0])
+	}
+	return names
+}
@@ -0,0 +1,182 @@
+package message
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxLength is the maximum length of a Telegram message in UTF-16 code units,
+// counted after entities parsing
+const MaxLength = 4096
+
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// Escape escapes the characters Telegram's HTML parse mode treats specially
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+type openTag struct {
+	name string
+	tag  string // As written, e.g. <a href="...">
+}
+
+// Builder accumulates a Telegram HTML message and splits it into parts at
+// line boundaries. Tags open at a split are closed at the end of the part
+// and reopened at the start of the next one.
+type Builder struct {
+	limit   int
+	parts   []string
+	part    strings.Builder
+	line    strings.Builder
+	length  int // Of the current part
+	hasText bool
+	open    []openTag
+}
+
+func NewBuilder() *Builder {
+	return &Builder{limit: MaxLength}
+}
+
+// WriteText appends escaped text
+func (b *Builder) WriteText(s string) {
+	b.WriteHTML(Escape(s))
+}
+
+// WriteHTML appends trusted HTML
+func (b *Builder) WriteHTML(s string) {
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			b.line.WriteString(s)
+			return
+		}
+		b.line.WriteString(s[:i+1])
+		b.writeLine(b.line.String())
+		b.line.Reset()
+		s = s[i+1:]
+	}
+}
+
+// Parts returns the message parts
+func (b *Builder) Parts() []string {
+	if b.line.Len() > 0 {
+		b.writeLine(b.line.String())
+		b.line.Reset()
+	}
+	b.flush()
+	return b.parts
+}
+
+func (b *Builder) writeLine(line string) {
+	tokens := tokenize(line)
+
+	length := 0
+	for _, token := range tokens {
+		length += token.length
+	}
+
+	// Start a new part if the line doesn't fit
+	if b.length > 0 && b.length+length > b.limit {
+		b.flush()
+	}
+
+	for _, token := range tokens {
+		// A line longer than the limit is split anywhere outside of tags and entities
+		if b.length > 0 && b.length+token.length > b.limit {
+			b.flush()
+		}
+		b.writeToken(token)
+	}
+}
+
+func (b *Builder) writeToken(t token) {
+	b.part.WriteString(t.value)
+	b.length += t.length
+	if t.length > 0 && strings.TrimFunc(t.value, unicode.IsSpace) != "" {
+		b.hasText = true
+	}
+
+	if !t.isTag {
+		return
+	}
+
+	name, closing := tagName(t.value)
+	if !closing {
+		b.open = append(b.open, openTag{name: name, tag: t.value})
+		return
+	}
+	for i := len(b.open) - 1; i >= 0; i-- {
+		if b.open[i].name == name {
+			b.open = append(b.open[:i], b.open[i+1:]...)
+			break
+		}
+	}
+}
+
+// flush ends the current part, closing the open tags, and reopens them in the next one
+func (b *Builder) flush() {
+	if b.hasText {
+		for i := len(b.open) - 1; i >= 0; i-- {
+			b.part.WriteString("</" + b.open[i].name + ">")
+		}
+		b.parts = append(b.parts, b.part.String())
+	}
+
+	b.part.Reset()
+	b.length = 0
+	b.hasText = false
+	for _, t := range b.open {
+		b.part.WriteString(t.tag)
+	}
+}
+
+type token struct {
+	value  string
+	length int // Visible length in UTF-16 code units
+	isTag  bool
+}
+
+// tokenize splits HTML into tags, entities and single characters
+func tokenize(s string) []token {
+	var tokens []token
+	for len(s) > 0 {
+		switch s[0] {
+		case '<':
+			if end := strings.IndexByte(s, '>'); end > 0 {
+				tokens = append(tokens, token{value: s[:end+1], isTag: true})
+				s = s[end+1:]
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(s, ';'); end > 1 && end <= 10 {
+				tokens = append(tokens, token{value: s[:end+1], length: 1})
+				s = s[end+1:]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		tokens = append(tokens, token{value: s[:size], length: utf16.RuneLen(r)})
+		s = s[size:]
+	}
+	return tokens
+}
+
+// tagName returns the lowercase name of a tag and whether it's a closing tag
+func tagName(tag string) (string, bool) {
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, "<"), ">")
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	if i := strings.IndexFunc(tag, unicode.IsSpace); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag), closing
+}
@@ -0,0 +1,308 @@
+package message
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+)
+
+// DefaultLocale is used when the user's language isn't supported. Other locales
+// fall back to its templates for messages they don't translate.
+const DefaultLocale = "en"
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+type localeTemplates struct {
+	html *template.Template // Messages, every action is escaped
+	text *template.Template // Buttons and keyboards, sent as plain text
+}
+
+var locales map[string]*localeTemplates
+
+// keyboardCommands maps the lowercase reply keyboard labels of every locale to their command
+var keyboardCommands map[string]string
+
+// Reply keyboard labels and the command they route to
+var keyboardButtons = [][]struct {
+	name    string
+	command string
+}{
+	{{"keyboard_watch", "watch"}, {"keyboard_unwatch", "unwatch"}},
+	{{"keyboard_watch_list", "watch list"}},
+}
+
+// Raw is trusted HTML that is written as is
+type Raw string
+
+var funcs = template.FuncMap{
+	"escape": escape,
+	"raw": func(v any) Raw {
+		return Raw(fmt.Sprint(v))
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context whose messages are rendered in the locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the locale of the context
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Locales returns the supported locales
+func Locales() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ResolveLocale returns the supported locale of a language tag (e.g. "id-ID"),
+// or an empty string if there is none
+func ResolveLocale(languageCode string) string {
+	languageCode = strings.ToLower(languageCode)
+	if _, ok := locales[languageCode]; ok {
+		return languageCode
+	}
+	language, _, _ := strings.Cut(languageCode, "-")
+	if _, ok := locales[language]; ok {
+		return language
+	}
+	return ""
+}
+
+// LoadTemplates parses the embedded message templates of every locale.
+// Templates in TEMPLATES_DIR override the embedded ones of the same name,
+// TEMPLATES_DIR/<locale> those of the other locales.
+func LoadTemplates() error {
+	entries, err := fs.ReadDir(embeddedTemplates, "templates")
+	if err != nil {
+		return fmt.Errorf("error listing templates: %v", err)
+	}
+
+	locales = make(map[string]*localeTemplates)
+	keyboardCommands = make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+
+		htmlT, err := parseLocale(locale)
+		if err != nil {
+			return err
+		}
+		// Escape the output of every action
+		for _, tmpl := range htmlT.Templates() {
+			if tmpl.Tree != nil {
+				escapeNode(tmpl.Tree.Root)
+			}
+		}
+
+		textT, err := parseLocale(locale)
+		if err != nil {
+			return err
+		}
+
+		locales[locale] = &localeTemplates{html: htmlT, text: textT}
+
+		for _, row := range keyboardButtons {
+			for _, button := range row {
+				label, err := execute(textT, button.name, nil)
+				if err != nil {
+					return fmt.Errorf("error rendering %s (%s): %v", button.name, locale, err)
+				}
+				keyboardCommands[strings.ToLower(label)] = button.command
+			}
+		}
+	}
+
+	if _, ok := locales[DefaultLocale]; !ok {
+		return fmt.Errorf("missing templates of the default locale")
+	}
+
+	return nil
+}
+
+// parseLocale parses the templates of the default locale, then those of the locale on top
+func parseLocale(locale string) (*template.Template, error) {
+	dirs := []string{DefaultLocale}
+	if locale != DefaultLocale {
+		dirs = append(dirs, locale)
+	}
+
+	t := template.New("").Funcs(funcs)
+	for _, dir := range dirs {
+		var err error
+		t, err = t.ParseFS(embeddedTemplates, "templates/"+dir+"/*.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("error parsing templates (%s): %v", dir, err)
+		}
+
+		if config.Cfg.TemplatesDir == "" {
+			continue
+		}
+		overrideDir := config.Cfg.TemplatesDir
+		if dir != DefaultLocale {
+			overrideDir = filepath.Join(overrideDir, dir)
+		}
+		files, err := filepath.Glob(filepath.Join(overrideDir, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing templates (%s): %v", dir, err)
+		}
+		if len(files) > 0 {
+			t, err = t.ParseFiles(files...)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing templates (%s): %v", dir, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func templatesOf(ctx context.Context) *localeTemplates {
+	if t, ok := locales[Locale(ctx)]; ok {
+		return t
+	}
+	return locales[DefaultLocale]
+}
+
+// Render executes the named message template in the locale of the context
+func Render(ctx context.Context, name string, data any) (string, error) {
+	if locales == nil {
+		return "", fmt.Errorf("templates are not loaded")
+	}
+	return execute(templatesOf(ctx).html, name, data)
+}
+
+// RenderText executes the named template as plain text in the locale of the context
+func RenderText(ctx context.Context, name string, data any) (string, error) {
+	if locales == nil {
+		return "", fmt.Errorf("templates are not loaded")
+	}
+	return execute(templatesOf(ctx).text, name, data)
+}
+
+// Text executes the named template as plain text, e.g. for button labels.
+// It returns the template name if the template fails.
+func Text(ctx context.Context, name string, data any) string {
+	text, err := RenderText(ctx, name, data)
+	if err != nil {
+		log.Printf("Message: %v", err)
+		return name
+	}
+	return text
+}
+
+func execute(t *template.Template, name string, data any) (string, error) {
+	var b strings.Builder
+	if err := t.ExecuteTemplate(&b, name, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// DefaultReplyMarkup returns the reply keyboard in the locale of the context
+func DefaultReplyMarkup(ctx context.Context) types.TelegramReplyKeyboardMarkup {
+	keyboard := make([][]string, len(keyboardButtons))
+	for i, row := range keyboardButtons {
+		for _, button := range row {
+			keyboard[i] = append(keyboard[i], Text(ctx, button.name, nil))
+		}
+	}
+	return types.TelegramReplyKeyboardMarkup{
+		Keyboard:       keyboard,
+		ResizeKeyboard: true,
+	}
+}
+
+// KeyboardCommand returns the command of a reply keyboard label in any locale
+func KeyboardCommand(text string) (string, bool) {
+	command, ok := keyboardCommands[strings.ToLower(strings.TrimSpace(text))]
+	return command, ok
+}
+
+// Split splits a long message into parts Telegram accepts
+func Split(text string) []string {
+	b := NewBuilder()
+	b.WriteHTML(text)
+	return b.Parts()
+}
+
+// escapeNode appends the escape function to the pipeline of every action that
+// prints something, like html/template does. Use raw to opt out.
+func escapeNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeNode(child)
+		}
+	case *parse.ActionNode:
+		// Variable declarations don't print anything
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier("escape").SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	case *parse.RangeNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	case *parse.WithNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	}
+}
+
+func escape(v any) Raw {
+	if raw, ok := v.(Raw); ok {
+		return raw
+	}
+
+	// Print what pointers point to, like text/template does
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+
+	return Raw(Escape(fmt.Sprint(rv.Interface())))
+}
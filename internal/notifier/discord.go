@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+const (
+	// Discord allows up to 10 embeds per message, 25 fields per embed, 1024 characters per
+	// field value and 6000 characters across the embeds of a message
+	discordMaxEmbeds     = 10
+	discordMaxFields     = 25
+	discordMaxFieldValue = 1024
+	discordMaxChars      = 6000
+
+	discordEmbedColor = 0x2b87d1
+)
+
+// Discord posts releases to a Discord incoming webhook as embeds, one per product
+type Discord struct {
+	webhook *database.Webhook
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title  string         `json:"title"`
+	Url    string         `json:"url"`
+	Color  int            `json:"color"`
+	Fields []discordField `json:"fields"`
+}
+
+type discordMessage struct {
+	Username string         `json:"username"`
+	Content  string         `json:"content"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+func (d *Discord) Ping(ctx context.Context) error {
+	return d.send(ctx, WebhookEventPing, discordMessage{
+		Username: "Version Watcher",
+		Content:  "Version Watcher is connected. New releases will be posted here.",
+	})
+}
+
+func (d *Discord) Notify(ctx context.Context, releases []*Release) error {
+	var embeds []discordEmbed
+	var size int
+	flush := func() error {
+		if len(embeds) == 0 {
+			return nil
+		}
+		err := d.send(ctx, WebhookEventRelease, discordMessage{
+			Username: "Version Watcher",
+			Content:  fmt.Sprintf("**%s**", title(releases)),
+			Embeds:   embeds,
+		})
+		embeds = nil
+		size = 0
+		return err
+	}
+
+	for _, p := range groupByProduct(releases) {
+		for _, embed := range discordProductEmbeds(p) {
+			embedSize := discordEmbedSize(embed)
+			if len(embeds) == discordMaxEmbeds || size+embedSize > discordMaxChars {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			embeds = append(embeds, embed)
+			size += embedSize
+		}
+	}
+	return flush()
+}
+
+func (d *Discord) send(ctx context.Context, event string, message discordMessage) error {
+	body, err := sonic.Marshal(message)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	return deliver(ctx, d.webhook, event, body)
+}
+
+// discordProductEmbeds returns the embeds of a product, its releases span several embeds if
+// they don't fit in one
+func discordProductEmbeds(p productReleases) []discordEmbed {
+	embed := discordEmbed{
+		Title: p.ProductLabel,
+		Url:   p.ProductUrl,
+		Color: discordEmbedColor,
+	}
+	embeds := []discordEmbed{}
+
+	for _, r := range p.Releases {
+		var valueB strings.Builder
+		valueB.WriteString(fmt.Sprintf("Label: %s\n", r.CycleLabel))
+		valueB.WriteString(fmt.Sprintf("Release: %s\n", r.FormattedDate()))
+		// A link too long for the field is left out
+		changelog := "Changelog: -"
+		if r.Link != nil && *r.Link != "" {
+			if link := fmt.Sprintf("Changelog: [link](%s)", *r.Link); valueB.Len()+len(link) <= discordMaxFieldValue {
+				changelog = link
+			}
+		}
+		valueB.WriteString(changelog)
+		field := discordField{
+			Name:  r.Version,
+			Value: valueB.String(),
+		}
+
+		full := len(embed.Fields) == discordMaxFields ||
+			discordEmbedSize(embed)+utf8.RuneCountInString(field.Name+field.Value) > discordMaxChars
+		if full && len(embed.Fields) > 0 {
+			embeds = append(embeds, embed)
+			embed.Fields = nil
+		}
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	return append(embeds, embed)
+}
+
+// discordEmbedSize returns the characters of an embed counted towards the limit of a message
+func discordEmbedSize(embed discordEmbed) int {
+	size := utf8.RuneCountInString(embed.Title)
+	for _, field := range embed.Fields {
+		size += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return size
+}
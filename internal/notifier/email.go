@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Email sends releases to a verified email address
+type Email struct {
+	subscription *database.EmailSubscription
+}
+
+func NewEmailChannel(subscription *database.EmailSubscription) *Email {
+	return &Email{subscription: subscription}
+}
+
+type emailData struct {
+	Release        *Release
+	Products       []productReleases
+	ProductLabels  string
+	UnsubscribeUrl string
+	Code           string
+}
+
+func (e *Email) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Notify sends a single release email, or a digest if there are several releases
+func (e *Email) Notify(ctx context.Context, releases []*Release) error {
+	if len(releases) == 0 {
+		return nil
+	}
+
+	groups := groupByProduct(releases)
+	data := emailData{
+		Products:       groups,
+		ProductLabels:  productLabels(groups),
+		UnsubscribeUrl: UnsubscribeUrl(e.subscription.UnsubscribeToken),
+	}
+
+	name := "mail_digest"
+	if len(releases) == 1 {
+		name = "mail_release"
+		data.Release = releases[0]
+	}
+
+	return sendEmail(ctx, e.subscription.Email, name, data)
+}
+
+// SendEmailVerification sends the confirmation code of a new email subscription
+func SendEmailVerification(ctx context.Context, subscription *database.EmailSubscription) error {
+	return sendEmail(ctx, subscription.Email, "mail_verification", emailData{
+		Code: subscription.VerificationCode,
+	})
+}
+
+// UnsubscribeUrl returns the link that removes an email subscription
+func UnsubscribeUrl(token string) string {
+	return fmt.Sprintf("%s/email/unsubscribe?token=%s", config.Cfg.WebhookURL, url.QueryEscape(token))
+}
+
+// sendEmail renders the subject and bodies of the named email in the locale of the context
+func sendEmail(ctx context.Context, to string, name string, data emailData) error {
+	subject, err := message.RenderText(ctx, name+"_subject", data)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	text, err := message.RenderText(ctx, name+"_text", data)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	html, err := message.Render(ctx, name+"_html", data)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	err = service.SendEmail(ctx, &service.SendEmailParams{
+		To:             to,
+		Subject:        subject,
+		Text:           text,
+		HTML:           html,
+		UnsubscribeUrl: data.UnsubscribeUrl,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package notifier
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/running"
+)
+
+const (
+	WebhookKindGeneric = "generic"
+	WebhookKindSlack   = "slack"
+	WebhookKindDiscord = "discord"
+)
+
+// Channel is a destination a watch list fans out new releases to
+type Channel interface {
+	// Ping lets the destination know it has been registered
+	Ping(ctx context.Context) error
+	// Notify sends new releases, ordered by product
+	Notify(ctx context.Context, releases []*Release) error
+}
+
+// Release is a new version of a watched product
+type Release struct {
+	ProductName  string  `json:"product"`
+	ProductLabel string  `json:"product_label"`
+	ProductUrl   string  `json:"product_url"`
+	Cycle        string  `json:"cycle"`
+	CycleLabel   string  `json:"cycle_label"`
+	Version      string  `json:"version"`
+	Date         *string `json:"date"` // YYYY-MM-DD
+	Link         *string `json:"link"`
+	// Set on the first release of a product if the chat recorded the version it runs
+	Running *running.Status `json:"running,omitempty"`
+}
+
+// FormattedDate returns the release date as displayed in messages (e.g. 8 Aug 2024)
+func (r *Release) FormattedDate() string {
+	if r.Date == nil {
+		return "-"
+	}
+	date, err := time.Parse("2006-01-02", *r.Date)
+	if err != nil {
+		return *r.Date
+	}
+	return date.Format("2 Jan 2006")
+}
+
+// ChangelogUrl returns the changelog link, or an empty string if there is none
+func (r *Release) ChangelogUrl() string {
+	if r.Link == nil {
+		return ""
+	}
+	return *r.Link
+}
+
+// NewWebhookChannel returns the channel matching the kind of the webhook
+func NewWebhookChannel(webhook *database.Webhook) Channel {
+	switch webhook.Kind {
+	case WebhookKindSlack:
+		return &Slack{webhook: webhook}
+	case WebhookKindDiscord:
+		return &Discord{webhook: webhook}
+	default:
+		return &Webhook{webhook: webhook}
+	}
+}
+
+// DetectWebhookKind tells incoming webhooks of Slack and Discord apart from generic endpoints
+func DetectWebhookKind(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "hooks.slack.com":
+		return WebhookKindSlack
+	case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(u.Path, "/api/webhooks/"):
+		return WebhookKindDiscord
+	default:
+		return WebhookKindGeneric
+	}
+}
+
+type productReleases struct {
+	ProductLabel string
+	ProductUrl   string
+	Releases     []*Release
+}
+
+// groupByProduct groups consecutive releases of the same product
+func groupByProduct(releases []*Release) []productReleases {
+	var groups []productReleases
+	for _, r := range releases {
+		if len(groups) == 0 || groups[len(groups)-1].ProductUrl != r.ProductUrl {
+			groups = append(groups, productReleases{
+				ProductLabel: r.ProductLabel,
+				ProductUrl:   r.ProductUrl,
+			})
+		}
+		groups[len(groups)-1].Releases = append(groups[len(groups)-1].Releases, r)
+	}
+	return groups
+}
+
+func productLabels(groups []productReleases) string {
+	labels := make([]string, 0, len(groups))
+	for _, p := range groups {
+		// A product split in several parts is listed once
+		if len(labels) > 0 && labels[len(labels)-1] == p.ProductLabel {
+			continue
+		}
+		labels = append(labels, p.ProductLabel)
+	}
+	return strings.Join(labels, ", ")
+}
+
+func title(releases []*Release) string {
+	if len(groupByProduct(releases)) > 1 {
+		return "New Releases Detected"
+	}
+	return "New Release Detected"
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+const (
+	// Slack allows up to 50 blocks per message, the header takes one
+	slackMaxSections = 45
+	// Slack allows up to 3000 characters in the text of a section
+	slackMaxSectionText = 3000
+)
+
+// Slack posts releases to a Slack incoming webhook using Block Kit
+type Slack struct {
+	webhook *database.Webhook
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks,omitempty"`
+}
+
+func (s *Slack) Ping(ctx context.Context) error {
+	return s.send(ctx, WebhookEventPing, slackMessage{
+		Text: "Version Watcher is connected. New releases will be posted here.",
+	})
+}
+
+func (s *Slack) Notify(ctx context.Context, releases []*Release) error {
+	groups := slackSplit(groupByProduct(releases))
+	for len(groups) > 0 {
+		n := min(len(groups), slackMaxSections)
+
+		blocks := []slackBlock{
+			{
+				Type: "header",
+				Text: &slackText{Type: "plain_text", Text: title(releases)},
+			},
+		}
+		for _, p := range groups[:n] {
+			blocks = append(blocks, slackBlock{
+				Type: "section",
+				Text: &slackText{Type: "mrkdwn", Text: slackSection(p)},
+			})
+		}
+
+		err := s.send(ctx, WebhookEventRelease, slackMessage{
+			// Fallback for notifications
+			Text:   fmt.Sprintf("%s: %s", title(releases), slackEscape(productLabels(groups[:n]))),
+			Blocks: blocks,
+		})
+		if err != nil {
+			return err
+		}
+		groups = groups[n:]
+	}
+	return nil
+}
+
+func (s *Slack) send(ctx context.Context, event string, message slackMessage) error {
+	body, err := sonic.Marshal(message)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	return deliver(ctx, s.webhook, event, body)
+}
+
+// slackSplit splits the products whose section would be too long, their releases span several sections
+func slackSplit(groups []productReleases) []productReleases {
+	var parts []productReleases
+	for _, p := range groups {
+		part := productReleases{ProductLabel: p.ProductLabel, ProductUrl: p.ProductUrl}
+		size := utf8.RuneCountInString(slackSectionHeader(p))
+		for _, r := range p.Releases {
+			releaseSize := utf8.RuneCountInString(slackSectionRelease(r))
+			if size+releaseSize > slackMaxSectionText && len(part.Releases) > 0 {
+				parts = append(parts, part)
+				part.Releases = nil
+				size = utf8.RuneCountInString(slackSectionHeader(p))
+			}
+			part.Releases = append(part.Releases, r)
+			size += releaseSize
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func slackSection(p productReleases) string {
+	var textB strings.Builder
+	textB.WriteString(slackSectionHeader(p))
+	for _, r := range p.Releases {
+		textB.WriteString(slackSectionRelease(r))
+	}
+	return textB.String()
+}
+
+func slackSectionHeader(p productReleases) string {
+	return fmt.Sprintf("*<%s|%s>*\n", p.ProductUrl, slackEscape(p.ProductLabel))
+}
+
+func slackSectionRelease(r *Release) string {
+	var textB strings.Builder
+	textB.WriteString(fmt.Sprintf("Version: `%s` | Label: %s\n", slackEscape(r.Version), slackEscape(r.CycleLabel)))
+	textB.WriteString(fmt.Sprintf("• Release: %s\n", r.FormattedDate()))
+	if r.Link != nil && *r.Link != "" {
+		textB.WriteString(fmt.Sprintf("• Changelog: <%s|link>\n", *r.Link))
+	} else {
+		textB.WriteString("• Changelog: -\n")
+	}
+	return textB.String()
+}
+
+// slackEscape escapes the control characters of Slack's mrkdwn
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
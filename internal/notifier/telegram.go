@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Telegram sends releases as HTML messages to a chat or channel, in the locale of the context
+type Telegram struct {
+	ChatId int64
+}
+
+func (t *Telegram) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (t *Telegram) Notify(ctx context.Context, releases []*Release) error {
+	texts, err := telegramTexts(ctx, releases)
+	if err != nil {
+		return err
+	}
+
+	for _, text := range texts {
+		err := service.SendMessage(ctx, &service.SendMessageParams{
+			ChatId:    t.ChatId,
+			ParseMode: service.TelegramParseModeHTML,
+			Text:      text,
+			LinkPreviewOptions: &types.TelegramLinkPreviewOptions{
+				IsDisabled: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func telegramTexts(ctx context.Context, releases []*Release) ([]string, error) {
+	text, err := message.Render(ctx, "releases", groupByProduct(releases))
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	// If text is too long, send it part by part
+	return message.Split(text), nil
+}
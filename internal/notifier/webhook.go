@@ -0,0 +1,234 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	WebhookEventRelease = "release"
+	WebhookEventPing    = "ping"
+
+	WebhookSignatureHeader = "X-Version-Watcher-Signature"
+	WebhookEventHeader     = "X-Version-Watcher-Event"
+
+	webhookMaxAttempts = 3
+)
+
+type WebhookPayload struct {
+	Event  string    `json:"event"`
+	SentAt time.Time `json:"sent_at"`
+	*Release
+}
+
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+			// The address is checked again once resolved, the host may resolve differently
+			// than when the webhook was registered
+			Control: func(network, address string, c syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if !IsPublicAddress(addrPort.Addr()) {
+					return ErrPrivateAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	Timeout: 10 * time.Second,
+}
+
+// ErrPrivateAddress is returned for webhook hosts that aren't reachable from the internet
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// IsPublicAddress reports whether webhooks can be sent to the address. Loopback, private,
+// link-local and unspecified addresses are only allowed in development, e.g. for a local receiver.
+func IsPublicAddress(addr netip.Addr) bool {
+	if config.Cfg.AppEnv == "development" {
+		return true
+	}
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// ResolvePublicHost resolves the host of a webhook URL, every address must be public
+func ResolvePublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddress(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// Sign returns the value of the signature header: "sha256=" followed by
+// the hex encoded HMAC-SHA256 of the body using the webhook secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook posts every release as a separate signed JSON event
+type Webhook struct {
+	webhook *database.Webhook
+}
+
+func (w *Webhook) Ping(ctx context.Context) error {
+	return w.send(ctx, WebhookEventPing, nil)
+}
+
+func (w *Webhook) Notify(ctx context.Context, releases []*Release) error {
+	var errs []error
+	for _, release := range releases {
+		if err := w.send(ctx, WebhookEventRelease, release); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Webhook) send(ctx context.Context, event string, release *Release) error {
+	body, err := sonic.Marshal(WebhookPayload{
+		Event:   event,
+		SentAt:  time.Now().UTC(),
+		Release: release,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return deliver(ctx, w.webhook, event, body)
+}
+
+// deliver posts the body to the webhook URL, retrying on network errors,
+// 429 and 5xx responses. Every attempt is recorded in webhook_deliveries.
+func deliver(ctx context.Context, webhook *database.Webhook, event string, body []byte) error {
+	backoff := time.Second
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		statusCode, err := postWebhook(ctx, webhook, event, body)
+		if err != nil {
+			log.Printf("Webhook: attempt %d to %s failed: %v", attempt, webhook.Url, err)
+		}
+
+		// Record delivery (ad-hoc webhooks, e.g. from the test-webhook command, aren't stored),
+		// also when the attempt was cut short by the deadline of the run
+		if webhook.ID != 0 {
+			recordDelivery(context.WithoutCancel(ctx), webhook, event, body, attempt, statusCode, err)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		// Client errors won't get better by retrying
+		if statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests {
+			return utils.NewError(err)
+		}
+
+		if attempt == webhookMaxAttempts {
+			return utils.NewError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return utils.NewError(ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return nil
+}
+
+func recordDelivery(ctx context.Context, webhook *database.Webhook, event string, body []byte, attempt int, statusCode int, deliveryErr error) {
+	var statusCodeP *int32
+	if statusCode != 0 {
+		statusCode32 := int32(statusCode)
+		statusCodeP = &statusCode32
+	}
+	var errorP *string
+	if deliveryErr != nil {
+		errorS := deliveryErr.Error()
+		errorP = &errorS
+	}
+
+	err := database.Sqlc.CreateWebhookDelivery(ctx, &database.CreateWebhookDeliveryParams{
+		WebhookID:  webhook.ID,
+		Event:      event,
+		Payload:    body,
+		Attempt:    int16(attempt),
+		StatusCode: statusCodeP,
+		Error:      errorP,
+		CreatedAt:  pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Webhook: error recording delivery: %v", err)
+	}
+}
+
+func postWebhook(ctx context.Context, webhook *database.Webhook, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VersionWatcherBot")
+	// Slack and Discord don't know about our headers, only generic webhooks are signed
+	if webhook.Kind == "" || webhook.Kind == WebhookKindGeneric {
+		req.Header.Set(WebhookEventHeader, event)
+		req.Header.Set(WebhookSignatureHeader, Sign(webhook.Secret, body))
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
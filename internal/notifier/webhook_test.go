@@ -0,0 +1,151 @@
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// deliveryLog records the webhook_deliveries rows instead of inserting them
+type deliveryLog struct {
+	mu   sync.Mutex
+	rows []database.CreateWebhookDeliveryParams
+}
+
+func (l *deliveryLog) Exec(_ context.Context, _ string, args ...interface{}) (pgconn.CommandTag, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rows = append(l.rows, database.CreateWebhookDeliveryParams{
+		WebhookID:  args[0].(int32),
+		Event:      args[1].(string),
+		Payload:    args[2].([]byte),
+		Attempt:    args[3].(int16),
+		StatusCode: args[4].(*int32),
+		Error:      args[5].(*string),
+	})
+	return pgconn.CommandTag{}, nil
+}
+
+func (l *deliveryLog) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	panic("unexpected query")
+}
+
+func (l *deliveryLog) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	panic("unexpected query")
+}
+
+// setupWebhookTest lets webhooks reach the local test server and records their deliveries
+func setupWebhookTest(t *testing.T) *deliveryLog {
+	t.Helper()
+
+	cfg, sqlc := config.Cfg, database.Sqlc
+	t.Cleanup(func() {
+		config.Cfg, database.Sqlc = cfg, sqlc
+	})
+
+	deliveries := &deliveryLog{}
+	config.Cfg = &config.Config{AppEnv: "development"}
+	database.Sqlc = database.New(deliveries)
+	return deliveries
+}
+
+func TestWebhookSignature(t *testing.T) {
+	deliveries := setupWebhookTest(t)
+	const secret = "s3cr3t"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if got := r.Header.Get(WebhookSignatureHeader); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if got := r.Header.Get(WebhookEventHeader); got != WebhookEventPing {
+			t.Errorf("event = %q, want %q", got, WebhookEventPing)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewWebhookChannel(&database.Webhook{ID: 1, Url: server.URL, Secret: secret})
+	if err := webhook.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	if len(deliveries.rows) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries.rows))
+	}
+	row := deliveries.rows[0]
+	if row.WebhookID != 1 || row.Event != WebhookEventPing || row.Attempt != 1 {
+		t.Errorf("delivery = %+v", row)
+	}
+	if row.StatusCode == nil || *row.StatusCode != http.StatusOK || row.Error != nil {
+		t.Errorf("delivery status = %v, error = %v", row.StatusCode, row.Error)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+	}{
+		{"server errors are retried", []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}, false},
+		{"attempts are limited", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, true},
+		{"client errors are not retried", []int{http.StatusBadRequest}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := setupWebhookTest(t)
+
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if i := int(requests.Add(1)) - 1; i < len(tt.statuses) {
+					w.WriteHeader(tt.statuses[i])
+				}
+			}))
+			defer server.Close()
+
+			webhook := NewWebhookChannel(&database.Webhook{ID: 1, Url: server.URL, Secret: "secret"})
+			err := webhook.Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ping error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got := int(requests.Load()); got != len(tt.statuses) {
+				t.Errorf("got %d requests, want %d", got, len(tt.statuses))
+			}
+			if len(deliveries.rows) != len(tt.statuses) {
+				t.Fatalf("got %d deliveries, want %d", len(deliveries.rows), len(tt.statuses))
+			}
+			for i, row := range deliveries.rows {
+				if row.Attempt != int16(i+1) {
+					t.Errorf("delivery %d: attempt = %d", i, row.Attempt)
+				}
+				if row.StatusCode == nil || int(*row.StatusCode) != tt.statuses[i] {
+					t.Errorf("delivery %d: status = %v, want %d", i, row.StatusCode, tt.statuses[i])
+				}
+				if (row.Error != nil) != (tt.statuses[i] != http.StatusOK) {
+					t.Errorf("delivery %d: error = %v", i, row.Error)
+				}
+			}
+		})
+	}
+}
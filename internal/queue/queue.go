@@ -0,0 +1,261 @@
+// Package queue processes Telegram updates in the background, so the webhook is acknowledged
+// right away. Updates of a chat always go to the same worker and are processed in order,
+// other chats in parallel. The Postgres backend keeps the updates not processed yet across
+// restarts and can be shared by several instances: an update dispatched by an instance which
+// didn't finish it in time is dispatched again.
+package queue
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/types"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+const (
+	// Updates waiting per worker
+	workerBufferSize = 100
+	// Updates taken from Postgres at once
+	dispatchBatchSize = 100
+	// Queued updates are picked up right away, polling only covers missed wake-ups
+	dispatchInterval = 5 * time.Second
+)
+
+var (
+	ErrFull    = errors.New("queue is full")
+	ErrStopped = errors.New("queue is stopped")
+)
+
+// Handler processes an update
+type Handler func(ctx context.Context, update types.TelegramUpdate)
+
+type Options struct {
+	Workers int
+	Backend string
+	// Maximum time to process an update
+	Timeout time.Duration
+}
+
+type Queue struct {
+	opts   Options
+	handle Handler
+
+	workers   []chan job
+	workersWg sync.WaitGroup
+
+	// Postgres backend only
+	wake           chan struct{}
+	stopDispatcher context.CancelFunc
+	dispatcherWg   sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+type job struct {
+	id     int64 // Row of queued_updates, 0 in memory
+	update types.TelegramUpdate
+}
+
+func New(opts Options, handle Handler) *Queue {
+	q := Queue{
+		opts:    opts,
+		handle:  handle,
+		workers: make([]chan job, max(opts.Workers, 1)),
+		wake:    make(chan struct{}, 1),
+	}
+	for i := range q.workers {
+		q.workers[i] = make(chan job, workerBufferSize)
+	}
+	return &q
+}
+
+// Start starts the workers, and with Postgres the dispatcher of the stored updates
+func (q *Queue) Start(ctx context.Context) {
+	for _, jobs := range q.workers {
+		q.workersWg.Add(1)
+		go q.work(ctx, jobs)
+	}
+
+	if q.opts.Backend == BackendPostgres {
+		dispatcherCtx, cancel := context.WithCancel(ctx)
+		q.stopDispatcher = cancel
+		q.dispatcherWg.Add(1)
+		go q.dispatch(dispatcherCtx)
+	}
+
+	log.Printf("Queue started: %d workers (%s)", len(q.workers), q.opts.Backend)
+}
+
+// Enqueue adds an update to the queue. ErrFull means the update should be delivered again later.
+func (q *Queue) Enqueue(ctx context.Context, update types.TelegramUpdate) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.stopped {
+		return ErrStopped
+	}
+
+	if q.opts.Backend == BackendPostgres {
+		payload, err := sonic.Marshal(update)
+		if err != nil {
+			return utils.NewError(err)
+		}
+		err = database.Sqlc.CreateQueuedUpdate(ctx, &database.CreateQueuedUpdateParams{
+			Payload:   payload,
+			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return utils.NewError(err)
+		}
+
+		// Wake the dispatcher up, unless it already is
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	select {
+	case q.workerOf(update) <- job{update: update}:
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// Stop stops taking updates and waits for the queued ones to be processed. With Postgres,
+// the updates not dispatched yet stay in the table for the next start.
+func (q *Queue) Stop() {
+	if q.stopDispatcher != nil {
+		q.stopDispatcher()
+		q.dispatcherWg.Wait()
+	}
+
+	q.mu.Lock()
+	q.stopped = true
+	for _, jobs := range q.workers {
+		close(jobs)
+	}
+	q.mu.Unlock()
+
+	q.workersWg.Wait()
+}
+
+// workerOf returns the worker of the chat of the update
+func (q *Queue) workerOf(update types.TelegramUpdate) chan job {
+	return q.workers[uint64(update.SerialKey())%uint64(len(q.workers))]
+}
+
+func (q *Queue) work(ctx context.Context, jobs <-chan job) {
+	defer q.workersWg.Done()
+	for j := range jobs {
+		q.process(ctx, j)
+	}
+}
+
+func (q *Queue) process(ctx context.Context, j job) {
+	// An update being processed is finished on shutdown
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.opts.Timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Queue: update %d: panic: %v", j.update.UpdateId, r)
+		}
+
+		// The update is done even if it timed out
+		if j.id != 0 {
+			err := database.Sqlc.DeleteQueuedUpdate(context.WithoutCancel(ctx), j.id)
+			if err != nil {
+				log.Printf("Queue: update %d: %v", j.update.UpdateId, err)
+			}
+		}
+	}()
+
+	q.handle(jobCtx, j.update)
+}
+
+// dispatch hands the updates stored in Postgres to the workers
+func (q *Queue) dispatch(ctx context.Context) {
+	defer q.dispatcherWg.Done()
+
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	var resetAt time.Time
+	for {
+		if time.Since(resetAt) >= dispatchInterval {
+			q.reset(ctx)
+			resetAt = time.Now()
+		}
+
+		rows, err := database.Sqlc.DispatchQueuedUpdates(ctx, &database.DispatchQueuedUpdatesParams{
+			DispatchedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+			Size:         dispatchBatchSize,
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Queue: dispatching: %v", err)
+		}
+
+		// RETURNING doesn't keep the order of the subquery
+		slices.SortFunc(rows, func(a, b *database.DispatchQueuedUpdatesRow) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		for _, row := range rows {
+			var update types.TelegramUpdate
+			if err := sonic.Unmarshal(row.Payload, &update); err != nil {
+				log.Printf("Queue: queued update %d: %v", row.ID, err)
+				_ = database.Sqlc.DeleteQueuedUpdate(ctx, row.ID)
+				continue
+			}
+
+			select {
+			case q.workerOf(update) <- job{id: row.ID, update: update}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// More are waiting
+		if len(rows) == dispatchBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// reset puts back the updates dispatched but not finished in time, e.g. by an instance which
+// stopped. An update is processed within the timeout, and its claim is taken over after
+// repository.UpdateClaimTimeout.
+func (q *Queue) reset(ctx context.Context) {
+	staleAfter := max(2*q.opts.Timeout, repository.UpdateClaimTimeout)
+	err := database.Sqlc.ResetQueuedUpdates(ctx, pgtype.Timestamp{
+		Time:  time.Now().Add(-staleAfter),
+		Valid: true,
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Queue: resetting: %v", err)
+	}
+}
@@ -9,8 +9,24 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-func TelegramGetChat(ctx context.Context, id int64) (*database.Chat, error) {
-	chat, err := database.Sqlc.GetChat(ctx, id)
+// Telegram gives up redelivering an update after 24 hours
+const ProcessedUpdateRetention = 24 * time.Hour
+
+// UpdateClaimTimeout is how long an update is being processed at most. A claim never completed
+// by then, e.g. after a crash, is taken over by the next delivery of the update.
+const UpdateClaimTimeout = time.Minute
+
+// DefaultChatTimeout is how long a conversation waits for the next message, unless the step sets its own
+const DefaultChatTimeout = 30 * time.Minute
+
+// TelegramGetChat returns the conversation state of a user in a chat, expired state is
+// reported as sql.ErrNoRows
+func TelegramGetChat(ctx context.Context, chatId int64, userId int64) (*database.Chat, error) {
+	chat, err := database.Sqlc.GetChat(ctx, &database.GetChatParams{
+		ID:     chatId,
+		UserID: userId,
+		Now:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
 	if err != nil {
 		return nil, utils.NewError(err)
 	}
@@ -20,39 +36,30 @@ func TelegramGetChat(ctx context.Context, id int64) (*database.Chat, error) {
 
 type TelegramSetChatParams struct {
 	ID      int64
+	UserID  int64
 	Command string
 	Step    int16
 	Data    []byte
+	// DefaultChatTimeout if zero
+	Timeout time.Duration
 }
 
 func TelegramSetChat(ctx context.Context, arg *TelegramSetChatParams) (*database.Chat, error) {
 	datetime := time.Now()
-
-	chatExists, err := database.Sqlc.IsChatExists(ctx, arg.ID)
-	if err != nil {
-		return nil, utils.NewError(err)
-	}
-
-	if chatExists {
-		chat, err := database.Sqlc.UpdateChat(ctx, &database.UpdateChatParams{
-			Command:   arg.Command,
-			Step:      arg.Step,
-			Data:      arg.Data,
-			UpdatedAt: pgtype.Timestamp{Time: datetime, Valid: true},
-			ID:        arg.ID,
-		})
-		if err != nil {
-			return nil, utils.NewError(err)
-		}
-
-		return chat, nil
+	timeout := arg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultChatTimeout
 	}
+	expiresAt := pgtype.Timestamp{Time: datetime.Add(timeout), Valid: true}
 
-	chat, err := database.Sqlc.CreateChat(ctx, &database.CreateChatParams{
+	// An upsert, concurrent updates of the chat can't both insert
+	chat, err := database.Sqlc.UpsertChat(ctx, &database.UpsertChatParams{
 		ID:        arg.ID,
+		UserID:    arg.UserID,
 		Command:   arg.Command,
 		Step:      arg.Step,
 		Data:      arg.Data,
+		ExpiresAt: expiresAt,
 		CreatedAt: pgtype.Timestamp{Time: datetime, Valid: true},
 	})
 	if err != nil {
@@ -62,11 +69,76 @@ func TelegramSetChat(ctx context.Context, arg *TelegramSetChatParams) (*database
 	return chat, nil
 }
 
-func TelegramDeleteChat(ctx context.Context, id int64) error {
-	err := database.Sqlc.DeleteChat(ctx, id)
+func TelegramDeleteChat(ctx context.Context, chatId int64, userId int64) error {
+	err := database.Sqlc.DeleteChat(ctx, &database.DeleteChatParams{
+		ID:     chatId,
+		UserID: userId,
+	})
 	if err != nil {
 		return utils.NewError(err)
 	}
 
 	return nil
 }
+
+// TelegramDeleteExpiredChats clears the conversations nobody finished, it returns how many
+func TelegramDeleteExpiredChats(ctx context.Context) (int64, error) {
+	rows, err := database.Sqlc.DeleteExpiredChats(ctx, pgtype.Timestamp{Time: time.Now(), Valid: true})
+	if err != nil {
+		return 0, utils.NewError(err)
+	}
+
+	return rows, nil
+}
+
+// TelegramClaimUpdate records an update as being processed. It returns false if it already
+// was, e.g. when Telegram redelivers an update whose response timed out.
+func TelegramClaimUpdate(ctx context.Context, updateId int64) (bool, error) {
+	datetime := time.Now()
+	rows, err := database.Sqlc.ClaimProcessedUpdate(ctx, &database.ClaimProcessedUpdateParams{
+		UpdateID:    updateId,
+		CreatedAt:   pgtype.Timestamp{Time: datetime, Valid: true},
+		StaleBefore: pgtype.Timestamp{Time: datetime.Add(-UpdateClaimTimeout), Valid: true},
+	})
+	if err != nil {
+		return false, utils.NewError(err)
+	}
+
+	return rows > 0, nil
+}
+
+// TelegramCompleteUpdate records a claimed update as processed, once its reply is sent
+func TelegramCompleteUpdate(ctx context.Context, updateId int64) error {
+	err := database.Sqlc.CompleteProcessedUpdate(ctx, &database.CompleteProcessedUpdateParams{
+		CompletedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		UpdateID:    updateId,
+	})
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
+
+// TelegramReleaseUpdate drops the claim of an update which failed, so it can be processed again
+func TelegramReleaseUpdate(ctx context.Context, updateId int64) error {
+	err := database.Sqlc.DeleteProcessedUpdate(ctx, updateId)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	return nil
+}
+
+// TelegramDeleteProcessedUpdates forgets the updates older than the retention, it returns how many
+func TelegramDeleteProcessedUpdates(ctx context.Context) (int64, error) {
+	rows, err := database.Sqlc.DeleteProcessedUpdates(ctx, pgtype.Timestamp{
+		Time:  time.Now().Add(-ProcessedUpdateRetention),
+		Valid: true,
+	})
+	if err != nil {
+		return 0, utils.NewError(err)
+	}
+
+	return rows, nil
+}
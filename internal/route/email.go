@@ -0,0 +1,67 @@
+package route
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EmailUnsubscribePage asks to confirm the unsubscription. Opening the link doesn't
+// unsubscribe, mail scanners and link previews open it too.
+func EmailUnsubscribePage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" {
+			return c.Status(400).Type("html").SendString(unsubscribePage("Invalid unsubscribe link."))
+		}
+
+		return c.Status(200).Type("html").SendString(unsubscribePage(fmt.Sprintf(
+			`Stop receiving new releases by email?</p>
+<form method="post" action="?token=%s">
+<button type="submit">Unsubscribe</button>
+</form>
+<p>`,
+			html.EscapeString(url.QueryEscape(token)),
+		)))
+	}
+}
+
+// EmailUnsubscribe removes the email subscription of the token. It's posted by the form of
+// the page and by mail clients supporting one-click unsubscribe (RFC 8058).
+func EmailUnsubscribe() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" {
+			return c.Status(400).Type("html").SendString(unsubscribePage("Invalid unsubscribe link."))
+		}
+
+		email, err := database.Sqlc.DeleteEmailSubscriptionByUnsubscribeToken(c.UserContext(), token)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(404).Type("html").SendString(unsubscribePage("This address is already unsubscribed."))
+			}
+			return utils.NewError(err)
+		}
+
+		return c.Status(200).Type("html").SendString(unsubscribePage(
+			fmt.Sprintf("%s will no longer receive new releases.", html.EscapeString(email)),
+		))
+	}
+}
+
+func unsubscribePage(message string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family: sans-serif;">
+<h3>Version Watcher Bot</h3>
+<p>%s</p>
+</body>
+</html>`, message)
+}
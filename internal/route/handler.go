@@ -1,148 +1,642 @@
 package route
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/chatlock"
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
 	"github.com/fidrasofyan/version-watcher-bot/internal/handler"
+	"github.com/fidrasofyan/version-watcher-bot/internal/message"
+	"github.com/fidrasofyan/version-watcher-bot/internal/queue"
 	"github.com/fidrasofyan/version-watcher-bot/internal/repository"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
 	"github.com/fidrasofyan/version-watcher-bot/internal/types"
 	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
-func Handler() fiber.Handler {
+// Handler receives the webhook. With a queue, the update is acknowledged right away and its
+// reply is sent through the Bot API by Deliver. Without, the reply is the webhook response.
+func Handler(q *queue.Queue) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req types.TelegramUpdate
 		if err := c.BodyParser(&req); err != nil {
 			return utils.NewError(err)
 		}
+		req.NormalizeChannelPost()
 
-		var chatId int64
-		var command string
-
-		// Is it callback query?
-		if req.CallbackQuery.Id != "" {
-			// Set chat id
-			chatId = req.CallbackQuery.From.Id
-		} else {
-			// Set chat id
-			chatId = req.Message.Chat.Id
-
-			// Only text message is supported
-			if req.Message.Text == "" {
-				return c.Status(200).JSON(types.TelegramResponse{
-					Method:      types.TelegramMethodSendMessage,
-					ChatId:      req.Message.Chat.Id,
-					ParseMode:   types.TelegramParseModeHTML,
-					Text:        "<i>Only text command is supported</i>",
-					ReplyMarkup: types.DefaultReplyMarkup,
-				})
-			}
-
-			// Set command
-			command = strings.TrimSpace(strings.ToLower(
-				req.Message.Text,
-			))
-			// Limit command length
-			if len(command) > 30 {
-				command = command[:30]
-			}
-			// Remove leading slashes
-			command = strings.TrimLeft(command, "/")
-		}
-
-		// Is it "cancel" command?
-		if command == "cancel" {
-			// Delete chat
-			err := repository.TelegramDeleteChat(c.UserContext(), chatId)
+		if q != nil {
+			err := q.Enqueue(c.UserContext(), req)
+			if errors.Is(err, queue.ErrFull) || errors.Is(err, queue.ErrStopped) {
+				// Telegram delivers the update again later
+				return c.Status(fiber.StatusServiceUnavailable).Send(nil)
+			}
 			if err != nil {
 				return utils.NewError(err)
 			}
-			return c.Status(200).JSON(types.TelegramResponse{
+			return c.Status(200).Send(nil)
+		}
+
+		err := Process(c.UserContext(), req, func(resp *types.TelegramResponse) error {
+			if resp == nil {
+				return c.Status(200).Send(nil)
+			}
+			return c.Status(200).JSON(resp)
+		})
+		if err != nil {
+			return utils.NewError(err)
+		}
+		return nil
+	}
+}
+
+// Deliver processes an update taken from the queue and sends the reply
+func Deliver(ctx context.Context, req types.TelegramUpdate) {
+	err := Process(ctx, req, func(resp *types.TelegramResponse) error {
+		if resp == nil {
+			return nil
+		}
+		return service.SendResponse(ctx, resp)
+	})
+	if err == nil {
+		return
+	}
+	log.Printf("Error: %v", err)
+
+	// The context may be what failed
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	resp := ErrorResponse(ctx, req, err)
+	if resp == nil {
+		return
+	}
+	err = service.SendResponse(ctx, resp)
+	if err != nil {
+		log.Printf("Error: %v", err)
+	}
+}
+
+// Process handles an update and hands its reply, nil if there is none, to reply. The update
+// is only recorded as processed once replied to, if it fails it can be processed again.
+func Process(ctx context.Context, req types.TelegramUpdate, reply func(resp *types.TelegramResponse) error) error {
+	// Redelivered updates were already handled
+	if req.UpdateId != 0 {
+		first, err := repository.TelegramClaimUpdate(ctx, req.UpdateId)
+		if err != nil {
+			return utils.NewError(err)
+		}
+		if !first {
+			return reply(nil)
+		}
+	}
+
+	err := processAndReply(ctx, req, reply)
+	if req.UpdateId == 0 {
+		return err
+	}
+
+	// The context may have expired
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		if releaseErr := repository.TelegramReleaseUpdate(ctx, req.UpdateId); releaseErr != nil {
+			log.Printf("Error: %v", releaseErr)
+		}
+		return err
+	}
+
+	err = repository.TelegramCompleteUpdate(ctx, req.UpdateId)
+	if err != nil {
+		// Replied already, a redelivery is skipped until the claim goes stale
+		log.Printf("Error: %v", err)
+	}
+	return nil
+}
+
+func processAndReply(ctx context.Context, req types.TelegramUpdate, reply func(resp *types.TelegramResponse) error) error {
+	// Updates of a chat are processed one at a time
+	unlock, err := chatlock.Lock(ctx, req.SerialKey())
+	if err != nil {
+		return utils.NewError(err)
+	}
+	defer unlock()
+
+	resp, err := process(ctx, req)
+	if err != nil {
+		return utils.NewError(err)
+	}
+
+	// Channels don't support reply keyboards
+	if resp != nil && req.ChatType() == types.TelegramChatTypeChannel {
+		if _, ok := resp.ReplyMarkup.(types.TelegramReplyKeyboardMarkup); ok {
+			resp.ReplyMarkup = nil
+		}
+	}
+
+	err = reply(resp)
+	if err != nil {
+		return utils.NewError(err)
+	}
+	return nil
+}
+
+// ErrorResponse tells the user the update failed and drops the session, whose step may be
+// half done. It returns nil if there is no chat to reply to.
+func ErrorResponse(ctx context.Context, req types.TelegramUpdate, err error) *types.TelegramResponse {
+	// The stored language isn't looked up, the database may be what failed
+	if locale := message.ResolveLocale(req.LanguageCode()); locale != "" {
+		ctx = message.WithLocale(ctx, locale)
+	}
+
+	name := "something_went_wrong"
+	if errors.Is(err, fiber.ErrRequestTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		name = "request_timeout"
+	}
+	text, renderErr := message.Render(ctx, name, nil)
+	if renderErr != nil {
+		log.Printf("Error: %v", renderErr)
+		text = "<i>Something went wrong</i>"
+	}
+
+	// Inline mode has no chat to reply to
+	if req.InlineQuery.Id != "" {
+		return nil
+	}
+	if req.CallbackQuery.InlineMessageId != "" {
+		_ = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+		return nil
+	}
+
+	// Delete chat
+	_ = repository.TelegramDeleteChat(ctx, req.ChatId(), req.UserId())
+
+	if req.CallbackQuery.Id != "" {
+		// Answer callback query
+		_ = service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+			CallbackQueryId: req.CallbackQuery.Id,
+		})
+
+		return &types.TelegramResponse{
+			Method:    types.TelegramMethodEditMessageText,
+			MessageId: req.CallbackQuery.Message.MessageId,
+			ChatId:    req.CallbackQuery.Message.Chat.Id,
+			ParseMode: types.TelegramParseModeHTML,
+			Text:      text,
+		}
+	}
+
+	return &types.TelegramResponse{
+		Method:      types.TelegramMethodSendMessage,
+		ChatId:      req.Message.Chat.Id,
+		ParseMode:   types.TelegramParseModeHTML,
+		Text:        text,
+		ReplyMarkup: message.DefaultReplyMarkup(ctx),
+	}
+}
+
+func process(ctx context.Context, req types.TelegramUpdate) (*types.TelegramResponse, error) {
+	// Set locale
+	locale, err := resolveLocale(ctx, req)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	ctx = message.WithLocale(ctx, locale)
+
+	// Inline mode
+	if req.InlineQuery.Id != "" {
+		err := handler.InlineQuery(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return nil, nil
+	}
+
+	// Buttons of messages sent via inline mode don't belong to a chat of the bot
+	if req.CallbackQuery.InlineMessageId != "" {
+		if strings.HasPrefix(req.CallbackQuery.Data, "watch_") {
+			err := handler.InlineWatch(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+		}
+		return nil, nil
+	}
+
+	chatId := req.ChatId()
+	userId := req.UserId()
+	var command string
+
+	// Is it a file?
+	if req.CallbackQuery.Id == "" && req.Message.Document.FileId != "" {
+		command = "import"
+	} else if req.CallbackQuery.Id == "" {
+		// Only text message is supported
+		if req.Message.Text == "" {
+			// Groups are full of non-text messages, ignore them
+			if !req.IsPrivateChat() {
+				return nil, nil
+			}
+
+			text, err := message.Render(ctx, "text_only", nil)
+			if err != nil {
+				return nil, utils.NewError(err)
+			}
+
+			return &types.TelegramResponse{
 				Method:      types.TelegramMethodSendMessage,
 				ChatId:      req.Message.Chat.Id,
 				ParseMode:   types.TelegramParseModeHTML,
-				Text:        "<i>Cancelled</i>",
-				ReplyMarkup: types.DefaultReplyMarkup,
-			})
+				Text:        text,
+				ReplyMarkup: message.DefaultReplyMarkup(ctx),
+			}, nil
 		}
 
-		// Get chat
-		chat, err := repository.TelegramGetChat(c.UserContext(), chatId)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return utils.NewError(err)
+		// Strip "@BotName" suffix
+		text, ok := stripBotUsername(req.Message.Text)
+		if !ok {
+			// Command is addressed to another bot
+			return nil, nil
+		}
+		req.Message.Text = text
+
+		// Set command
+		command = strings.TrimSpace(strings.ToLower(
+			req.Message.Text,
+		))
+		// Limit command length
+		if len(command) > 30 {
+			command = command[:30]
 		}
-		if chat != nil {
-			// Set command
-			command = chat.Command
+		// Remove leading slashes
+		command = strings.TrimLeft(command, "/")
+		// Reply keyboard labels are translated
+		if keyboardCommand, ok := message.KeyboardCommand(command); ok {
+			command = keyboardCommand
 		}
+	}
 
-		switch command {
-		// Start
-		case "start":
-			resp, err := handler.Start(c.UserContext(), req)
-			if err != nil {
-				return utils.NewError(err)
+	// Bulk unwatch ticks every product of the selection
+	if command == "unwatch all" || req.CallbackQuery.Data == "unwatch_select" {
+		command = "unwatch_select"
+	}
+
+	// Is it "cancel" command?
+	if command == "cancel" {
+		// Delete chat
+		err := repository.TelegramDeleteChat(ctx, chatId, userId)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		text, err := message.Render(ctx, "canceled", nil)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return &types.TelegramResponse{
+			Method:      types.TelegramMethodSendMessage,
+			ChatId:      req.Message.Chat.Id,
+			ParseMode:   types.TelegramParseModeHTML,
+			Text:        text,
+			ReplyMarkup: message.DefaultReplyMarkup(ctx),
+		}, nil
+	}
+
+	// History pages don't need a session
+	if strings.HasPrefix(req.CallbackQuery.Data, "history_") {
+		resp, err := handler.HistoryPage(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+	}
+
+	// Get chat
+	chat, err := repository.TelegramGetChat(ctx, chatId, userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.NewError(err)
+	}
+	// A file always starts an import
+	if chat != nil && req.Message.Document.FileId == "" {
+		// Set command
+		command = chat.Command
+	}
+
+	// Groups are full of files, there a file is only imported when it's sent with /import as
+	// caption or while an import of the member waits for it
+	if req.Message.Document.FileId != "" && !req.IsPrivateChat() &&
+		!isImportCaption(req.Message.Caption) && (chat == nil || chat.Command != "import") {
+		return nil, nil
+	}
+
+	// Sessions are per member, a button of a group message without a session was pressed by
+	// another member, or the session ended. The message may still be used by its session.
+	if chat == nil && req.CallbackQuery.Id != "" && !req.IsPrivateChat() {
+		err := answerAlert(ctx, req, "invalid_session_alert")
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return nil, nil
+	}
+
+	// Only administrators can manage the watch list of a group, on every step of a session so an
+	// administrator who was demoted meanwhile can't finish it
+	if isManagementCommand(command) {
+		isAdmin, err := isChatAdmin(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		if !isAdmin {
+			// A button press is answered to the user who pressed it, not to the group
+			if req.CallbackQuery.Id != "" {
+				err := answerAlert(ctx, req, "admin_only_alert")
+				if err != nil {
+					return nil, utils.NewError(err)
+				}
+				return nil, nil
 			}
-			if resp == nil {
-				return c.Status(200).Send(nil)
+
+			text, err := message.Render(ctx, "admin_only", nil)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
-			return c.Status(200).JSON(resp)
 
-		// Watch
-		case "watch":
-			resp, err := handler.Watch(c.UserContext(), req)
+			return &types.TelegramResponse{
+				Method:    types.TelegramMethodSendMessage,
+				ChatId:    req.ChatId(),
+				ParseMode: types.TelegramParseModeHTML,
+				Text:      text,
+			}, nil
+		}
+	}
+
+	switch command {
+	// Help
+	case "help":
+		resp, err := handler.Help(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Watch
+	case "watch":
+		resp, err := handler.Watch(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Watch list
+	case "watch list":
+		resp, err := handler.WatchList(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Unwatch
+	case "unwatch":
+		resp, err := handler.Unwatch(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Unwatch several products
+	case "unwatch_select":
+		resp, err := handler.UnwatchSelect(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Import from a dependency file
+	case "import":
+		resp, err := handler.Import(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Channel
+	case "channel":
+		resp, err := handler.Channel(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Webhook
+	case "webhook":
+		resp, err := handler.Webhook(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Email
+	case "email":
+		resp, err := handler.Email(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Language
+	case "language":
+		resp, err := handler.Language(ctx, req)
+		if err != nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	// Not found
+	default:
+		// Start, optionally followed by a deep link payload
+		if command == "start" || strings.HasPrefix(command, "start ") {
+			resp, err := handler.Start(ctx, req)
 			if err != nil {
-				return utils.NewError(err)
+				return nil, utils.NewError(err)
 			}
-			if resp == nil {
-				return c.Status(200).Send(nil)
+			return resp, nil
+		}
+
+		// Watch, followed by several products
+		if strings.HasPrefix(command, "watch ") {
+			resp, err := handler.WatchBulk(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
-			return c.Status(200).JSON(resp)
+			return resp, nil
+		}
 
-		// Watch list
-		case "watch list":
-			resp, err := handler.WatchList(c.UserContext(), req)
+		// Export, optionally followed by the format
+		if command == "export" || strings.HasPrefix(command, "export ") {
+			resp, err := handler.Export(ctx, req)
 			if err != nil {
-				return utils.NewError(err)
+				return nil, utils.NewError(err)
 			}
-			if resp == nil {
-				return c.Status(200).Send(nil)
+			return resp, nil
+		}
+
+		// Collection, optionally followed by the action
+		if command == "collection" || strings.HasPrefix(command, "collection ") {
+			resp, err := handler.Collection(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
-			return c.Status(200).JSON(resp)
+			return resp, nil
+		}
 
-		// Unwatch
-		case "unwatch":
-			resp, err := handler.UnwatchStep1(c.UserContext(), req)
+		// Share, optionally followed by the product
+		if command == "share" || strings.HasPrefix(command, "share ") {
+			resp, err := handler.Share(ctx, req)
 			if err != nil {
-				return utils.NewError(err)
+				return nil, utils.NewError(err)
 			}
-			if resp == nil {
-				return c.Status(200).Send(nil)
+			return resp, nil
+		}
+
+		// Running, followed by the product and version
+		if command == "running" || strings.HasPrefix(command, "running ") {
+			resp, err := handler.Running(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
-			return c.Status(200).JSON(resp)
+			return resp, nil
+		}
 
-		// Not found
-		default:
-			if strings.HasPrefix(command, "unwatch_") {
-				resp, err := handler.UnwatchStep2(c.UserContext(), req)
-				if err != nil {
-					return utils.NewError(err)
-				}
-				if resp == nil {
-					return c.Status(200).Send(nil)
-				}
-				return c.Status(200).JSON(resp)
+		// History, followed by the product
+		if command == "history" || strings.HasPrefix(command, "history ") {
+			resp, err := handler.History(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
+			return resp, nil
+		}
 
-			resp, err := handler.NotFound(c.UserContext(), req)
-			if err != nil || resp == nil {
-				return utils.NewError(err)
+		// Version, followed by the product and cycle
+		if command == "version" || strings.HasPrefix(command, "version ") {
+			resp, err := handler.Version(ctx, req)
+			if err != nil {
+				return nil, utils.NewError(err)
 			}
-			return c.Status(200).JSON(resp)
+			return resp, nil
+		}
+
+		// Don't reply to every unknown message in groups
+		if !req.IsPrivateChat() && req.CallbackQuery.Id == "" {
+			return nil, nil
+		}
+
+		resp, err := handler.NotFound(ctx, req)
+		if err != nil || resp == nil {
+			return nil, utils.NewError(err)
+		}
+		return resp, nil
+
+	}
+}
+
+// stripBotUsername removes the "@BotName" suffix from a command (e.g. "/watch@BotName").
+// It returns false if the command is addressed to another bot.
+func stripBotUsername(text string) (string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return text, true
+	}
 
+	name, args, _ := strings.Cut(text, " ")
+	name, botUsername, found := strings.Cut(name, "@")
+	if !found {
+		return text, true
+	}
+
+	if config.Cfg.TelegramBotUsername != "" && !strings.EqualFold(botUsername, config.Cfg.TelegramBotUsername) {
+		return "", false
+	}
+
+	return strings.TrimSpace(name + " " + args), true
+}
+
+// isImportCaption reports whether the caption of a file is the /import command of this bot
+func isImportCaption(caption string) bool {
+	text, ok := stripBotUsername(strings.TrimSpace(caption))
+	return ok && strings.EqualFold(text, "/import")
+}
+
+// resolveLocale returns the language chosen with /language, or the one of the user's client
+func resolveLocale(ctx context.Context, req types.TelegramUpdate) (string, error) {
+	if req.ChatType() == types.TelegramChatTypeChannel {
+		return message.DefaultLocale, nil
+	}
+
+	language, err := database.Sqlc.GetUserLanguage(ctx, req.UserId())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", utils.NewError(err)
+	}
+	if language != nil {
+		if locale := message.ResolveLocale(*language); locale != "" {
+			return locale, nil
 		}
 	}
+
+	if locale := message.ResolveLocale(req.LanguageCode()); locale != "" {
+		return locale, nil
+	}
+
+	return message.DefaultLocale, nil
+}
+
+// answerAlert answers a button press with an alert shown to the user who pressed it
+func answerAlert(ctx context.Context, req types.TelegramUpdate, name string) error {
+	text := message.Text(ctx, name, nil)
+	showAlert := true
+	return service.AnswerCallbackQuery(ctx, &service.AnswerCallbackQueryParams{
+		CallbackQueryId: req.CallbackQuery.Id,
+		Text:            &text,
+		ShowAlert:       &showAlert,
+	})
+}
+
+func isManagementCommand(command string) bool {
+	return command == "watch" ||
+		strings.HasPrefix(command, "watch ") ||
+		command == "unwatch" ||
+		command == "channel" ||
+		command == "webhook" ||
+		command == "email" ||
+		command == "unwatch_select" ||
+		command == "import" ||
+		command == "running" ||
+		strings.HasPrefix(command, "running ") ||
+		strings.HasPrefix(command, "start col_") ||
+		strings.HasPrefix(command, "start watch_") ||
+		strings.HasPrefix(command, "collection leave ")
+}
+
+func isChatAdmin(ctx context.Context, req types.TelegramUpdate) (bool, error) {
+	switch req.ChatType() {
+	case types.TelegramChatTypeGroup, types.TelegramChatTypeSupergroup:
+	default:
+		// Private chats are owned by the user, channel posts are made by administrators
+		return true, nil
+	}
+
+	member, err := service.GetChatMember(ctx, &service.GetChatMemberParams{
+		ChatId: req.ChatId(),
+		UserId: req.UserId(),
+	})
+	if err != nil {
+		return false, utils.NewError(err)
+	}
+
+	return member.Status == types.TelegramChatMemberStatusCreator ||
+		member.Status == types.TelegramChatMemberStatusAdministrator, nil
 }
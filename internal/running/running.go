@@ -0,0 +1,215 @@
+// Package running tells how far the version a chat runs (set with /running) is behind the latest release
+package running
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fidrasofyan/version-watcher-bot/database"
+	"github.com/fidrasofyan/version-watcher-bot/internal/service"
+	"github.com/fidrasofyan/version-watcher-bot/internal/utils"
+)
+
+// Status of a running version, Cycle is empty if the version matches no release cycle
+type Status struct {
+	Version    string  `json:"version"`
+	Cycle      string  `json:"cycle,omitempty"`
+	CycleLabel string  `json:"cycle_label,omitempty"`
+	Latest     string  `json:"latest,omitempty"`
+	Behind     int     `json:"behind"`
+	Eol        bool    `json:"eol"`
+	EolFrom    *string `json:"eol_from,omitempty"` // YYYY-MM-DD
+}
+
+// FormattedEolFrom returns the EOL date as displayed in messages (e.g. 8 Aug 2024)
+func (s *Status) FormattedEolFrom() string {
+	if s.EolFrom == nil {
+		return ""
+	}
+	date, err := time.Parse("2006-01-02", *s.EolFrom)
+	if err != nil {
+		return *s.EolFrom
+	}
+	return date.Format("2 Jan 2006")
+}
+
+// Check compares a running version with the releases of its cycle. Releases come from the
+// cached endoflife.date data, versions from the ones detected so far.
+func Check(ctx context.Context, productId int32, apiUrl string, version string) (*Status, error) {
+	status := Status{
+		Version: version,
+	}
+
+	product, err := service.GetCachedEndOfLifeProduct(ctx, apiUrl)
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+
+	release := findCycle(product.Releases, version)
+	if release == nil {
+		return &status, nil
+	}
+	status.Cycle = release.Name
+	status.CycleLabel = release.Label
+	status.Eol = release.IsEol
+	status.EolFrom = release.EolFrom
+
+	versions, err := database.Sqlc.GetCycleVersions(ctx, &database.GetCycleVersionsParams{
+		ProductID:   productId,
+		ReleaseName: release.Name,
+	})
+	if err != nil {
+		return nil, utils.NewError(err)
+	}
+	// Versions released before the product was first populated are unknown, the latest one isn't
+	if release.Latest != nil && release.Latest.Name != nil {
+		status.Latest = *release.Latest.Name
+		if !containsVersion(versions, status.Latest) {
+			versions = append(versions, status.Latest)
+		}
+	}
+
+	for _, v := range versions {
+		if Compare(v, version) > 0 {
+			status.Behind++
+		}
+		if status.Latest == "" || Compare(v, status.Latest) > 0 {
+			status.Latest = v
+		}
+	}
+
+	return &status, nil
+}
+
+// findCycle returns the release cycle of a version, the one with the longest matching name
+// (e.g. 1.25.3 is in 1.25 rather than 1)
+func findCycle(releases []service.EndOfLifeRelease, version string) *service.EndOfLifeRelease {
+	version = trimPrefix(version)
+	var found *service.EndOfLifeRelease
+	for i, release := range releases {
+		name := trimPrefix(release.Name)
+		if !strings.EqualFold(version, name) && !hasVersionPrefix(version, name) {
+			continue
+		}
+		if found == nil || len(release.Name) > len(found.Name) {
+			found = &releases[i]
+		}
+	}
+	return found
+}
+
+// hasVersionPrefix reports whether name is a leading part of version (e.g. 16 of 16.2 or 16-alpine)
+func hasVersionPrefix(version string, name string) bool {
+	if len(version) <= len(name) || !strings.EqualFold(version[:len(name)], name) {
+		return false
+	}
+	return isSeparator(rune(version[len(name)]))
+}
+
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if Compare(v, version) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare orders versions part by part, numbers numerically (1.10 > 1.9) and pre-releases
+// before the release (1.0-rc1 < 1.0). It returns -1, 0 or 1 like strings.Compare.
+func Compare(a string, b string) int {
+	partsA := split(trimPrefix(a))
+	partsB := split(trimPrefix(b))
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		switch {
+		case i >= len(partsA):
+			if order := extraPartOrder(partsB[i]); order != 0 {
+				return -order
+			}
+			continue
+		case i >= len(partsB):
+			if order := extraPartOrder(partsA[i]); order != 0 {
+				return order
+			}
+			continue
+		}
+
+		numA, errA := strconv.ParseUint(partsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(partsB[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA > numB {
+					return 1
+				}
+				return -1
+			}
+		// Numbers come after words (1.0.1 > 1.0-rc1)
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(partsA[i]), strings.ToLower(partsB[i])); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// extraPartOrder tells whether a version with one more part comes after (1.0.1), before (1.0-rc1)
+// or is the same (1.0.0)
+func extraPartOrder(part string) int {
+	num, err := strconv.ParseUint(part, 10, 64)
+	switch {
+	case err != nil:
+		return -1
+	case num == 0:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// split splits a version into parts, letters and numbers apart (rc1 -> rc, 1)
+func split(version string) []string {
+	var parts []string
+	start := -1
+	for i, r := range version {
+		if isSeparator(r) {
+			if start >= 0 {
+				parts = append(parts, version[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsDigit(r) != unicode.IsDigit(rune(version[i-1])) {
+			parts = append(parts, version[start:i])
+			start = i
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, version[start:])
+	}
+	return parts
+}
+
+func isSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '_' || r == '+' || r == ' '
+}
+
+// trimPrefix removes the "v" of tags such as v1.2.3
+func trimPrefix(version string) string {
+	version = strings.TrimSpace(version)
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && unicode.IsDigit(rune(version[1])) {
+		return version[1:]
+	}
+	return version
+}
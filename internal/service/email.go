@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/fidrasofyan/version-watcher-bot/internal/config"
+)
+
+type SendEmailParams struct {
+	To             string
+	Subject        string
+	Text           string
+	HTML           string
+	UnsubscribeUrl string
+}
+
+// IsEmailEnabled reports whether SMTP is configured
+func IsEmailEnabled() bool {
+	return config.Cfg.SmtpHost != ""
+}
+
+func SendEmail(ctx context.Context, params *SendEmailParams) error {
+	from, err := mail.ParseAddress(config.Cfg.SmtpFrom)
+	if err != nil {
+		return fmt.Errorf("parsing SMTP_FROM: %v", err)
+	}
+
+	message, err := buildEmail(from, params)
+	if err != nil {
+		return err
+	}
+
+	host := config.Cfg.SmtpHost
+	addr := net.JoinHostPort(host, config.Cfg.SmtpPort)
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	// Port 465 uses implicit TLS, the others upgrade with STARTTLS
+	var conn net.Conn
+	if config.Cfg.SmtpPort == "465" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if config.Cfg.SmtpUsername != "" {
+		auth := smtp.PlainAuth("", config.Cfg.SmtpUsername, config.Cfg.SmtpPassword, host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(params.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildEmail builds a multipart/alternative message with plain text and HTML parts
+func buildEmail(from *mail.Address, params *SendEmailParams) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", params.Text},
+		{"text/html; charset=utf-8", params.HTML},
+	}
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	messageId := make([]byte, 16)
+	if _, err := rand.Read(messageId); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", params.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", params.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(messageId), config.Cfg.SmtpHost)
+	if params.UnsubscribeUrl != "" {
+		fmt.Fprintf(&msg, "List-Unsubscribe: <%s>\r\n", params.UnsubscribeUrl)
+		msg.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+type EndOfLifeLatestRelease struct {
+	Name *string `json:"name"`
+	Date *string `json:"date"` // YYYY-MM-DD
+	Link *string `json:"link"`
+}
+
+type EndOfLifeCustom struct {
+	APIVersion *string `json:"apiVersion"`
+}
+
+type EndOfLifeRelease struct {
+	Name         string                  `json:"name"`
+	Codename     *string                 `json:"codename"`
+	Label        string                  `json:"label"`
+	ReleaseDate  *string                 `json:"releaseDate"`
+	IsEol        bool                    `json:"isEol"`
+	EolFrom      *string                 `json:"eolFrom"`
+	IsMaintained bool                    `json:"isMaintained"`
+	Latest       *EndOfLifeLatestRelease `json:"latest"`
+	Custom       *EndOfLifeCustom        `json:"custom"`
+}
+
+type EndOfLifeProduct struct {
+	Name     string             `json:"name"`
+	Label    string             `json:"label"`
+	Category string             `json:"category"`
+	Releases []EndOfLifeRelease `json:"releases"` // Latest first
+}
+
+// GetEndOfLifeProduct fetches the release cycles of a product from endoflife.date and caches them
+func GetEndOfLifeProduct(ctx context.Context, apiUrl string) (*EndOfLifeProduct, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", apiUrl, res.Status)
+	}
+
+	var resBody struct {
+		Result EndOfLifeProduct `json:"result"`
+	}
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, err
+	}
+
+	endOfLifeCache.Lock()
+	defer endOfLifeCache.Unlock()
+	// Drop expired entries
+	for url, entry := range endOfLifeCache.entries {
+		if time.Since(entry.fetchedAt) >= endOfLifeCacheTTL {
+			delete(endOfLifeCache.entries, url)
+		}
+	}
+	endOfLifeCache.entries[apiUrl] = endOfLifeCacheEntry{
+		product:   &resBody.Result,
+		fetchedAt: time.Now(),
+	}
+
+	return &resBody.Result, nil
+}
+
+const endOfLifeCacheTTL = 10 * time.Minute
+
+type endOfLifeCacheEntry struct {
+	product   *EndOfLifeProduct
+	fetchedAt time.Time
+}
+
+var endOfLifeCache = struct {
+	sync.Mutex
+	entries map[string]endOfLifeCacheEntry
+}{
+	entries: make(map[string]endOfLifeCacheEntry),
+}
+
+// GetCachedEndOfLifeProduct is GetEndOfLifeProduct for user requests, products fetched
+// in the last minutes are served from memory
+func GetCachedEndOfLifeProduct(ctx context.Context, apiUrl string) (*EndOfLifeProduct, error) {
+	endOfLifeCache.Lock()
+	entry, ok := endOfLifeCache.entries[apiUrl]
+	endOfLifeCache.Unlock()
+	if ok && time.Since(entry.fetchedAt) < endOfLifeCacheTTL {
+		return entry.product, nil
+	}
+
+	return GetEndOfLifeProduct(ctx, apiUrl)
+}
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -64,6 +68,38 @@ func SendMessage(ctx context.Context, params *SendMessageParams) error {
 	return nil
 }
 
+// SendResponse calls the method of a webhook response, for replies sent after the webhook
+// was acknowledged
+func SendResponse(ctx context.Context, resp *types.TelegramResponse) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", config.Cfg.TelegramBotToken, resp.Method)
+	jsonData, err := sonic.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[any]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return err
+	}
+	if !resBody.Ok {
+		return &TelegramError{Method: string(resp.Method), Description: resBody.Description}
+	}
+
+	return nil
+}
+
 type AnswerCallbackQueryParams struct {
 	CallbackQueryId string  `json:"callback_query_id"`
 	Text            *string `json:"text,omitempty"`
@@ -92,6 +128,44 @@ func AnswerCallbackQuery(ctx context.Context, params *AnswerCallbackQueryParams)
 	return nil
 }
 
+type AnswerInlineQueryParams struct {
+	InlineQueryId string                                   `json:"inline_query_id"`
+	Results       []types.TelegramInlineQueryResultArticle `json:"results"`
+	CacheTime     int                                      `json:"cache_time"`
+	IsPersonal    bool                                     `json:"is_personal"`
+	NextOffset    string                                   `json:"next_offset,omitempty"`
+}
+
+func AnswerInlineQuery(ctx context.Context, params *AnswerInlineQueryParams) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerInlineQuery", config.Cfg.TelegramBotToken)
+	jsonData, err := sonic.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[bool]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return err
+	}
+	if !resBody.Ok {
+		return &TelegramError{Method: "answerInlineQuery", Description: resBody.Description}
+	}
+
+	return nil
+}
+
 func SetWebhook(ctx context.Context) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/setWebhook", config.Cfg.TelegramBotToken)
 	data := struct {
@@ -105,7 +179,7 @@ func SetWebhook(ctx context.Context) error {
 		SecretToken:        config.Cfg.WebhookSecretToken,
 		MaxConnections:     50,
 		DropPendingUpdates: true,
-		AllowedUpdates:     []string{"message", "callback_query"},
+		AllowedUpdates:     []string{"message", "channel_post", "callback_query", "inline_query"},
 	}
 	jsonData, err := sonic.Marshal(data)
 	if err != nil {
@@ -132,14 +206,25 @@ type Command struct {
 	Description string `json:"description"`
 }
 
-func SetMyCommands(ctx context.Context, commands []Command) error {
+const (
+	CommandScopeAllPrivateChats = "all_private_chats"
+	CommandScopeAllGroupChats   = "all_group_chats"
+)
+
+type CommandScope struct {
+	Type string `json:"type"`
+}
+
+type SetMyCommandsParams struct {
+	Commands []Command     `json:"commands"`
+	Scope    *CommandScope `json:"scope,omitempty"`
+	// Users whose client is in this language see these commands, empty for everyone else
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+func SetMyCommands(ctx context.Context, params *SetMyCommandsParams) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/setMyCommands", config.Cfg.TelegramBotToken)
-	data := struct {
-		Commands []Command `json:"commands"`
-	}{
-		Commands: commands,
-	}
-	jsonData, err := sonic.Marshal(data)
+	jsonData, err := sonic.Marshal(params)
 	if err != nil {
 		return err
 	}
@@ -156,5 +241,220 @@ func SetMyCommands(ctx context.Context, commands []Command) error {
 	}
 	defer res.Body.Close()
 
+	var resBody telegramResponse[bool]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return err
+	}
+	if !resBody.Ok {
+		return &TelegramError{Method: "setMyCommands", Description: resBody.Description}
+	}
+
+	return nil
+}
+
+type telegramResponse[T any] struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      T      `json:"result"`
+}
+
+// TelegramError is returned when the Bot API rejects a request (e.g. chat not found)
+type TelegramError struct {
+	Method      string
+	Description string
+}
+
+func (e *TelegramError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Method, e.Description)
+}
+
+// BotId returns the bot's user id, which is the first part of the bot token
+func BotId() int64 {
+	id, _, _ := strings.Cut(config.Cfg.TelegramBotToken, ":")
+	botId, _ := strconv.ParseInt(id, 10, 64)
+	return botId
+}
+
+type GetChatParams struct {
+	// Chat id or username of the target channel (in the format @channelusername)
+	ChatId any `json:"chat_id"`
+}
+
+func GetChat(ctx context.Context, params *GetChatParams) (*types.TelegramChat, error) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/getChat", config.Cfg.TelegramBotToken)
+	jsonData, err := sonic.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[types.TelegramChat]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, err
+	}
+	if !resBody.Ok {
+		return nil, &TelegramError{Method: "getChat", Description: resBody.Description}
+	}
+
+	return &resBody.Result, nil
+}
+
+type GetChatMemberParams struct {
+	ChatId int64 `json:"chat_id"`
+	UserId int64 `json:"user_id"`
+}
+
+func GetChatMember(ctx context.Context, params *GetChatMemberParams) (*types.TelegramChatMember, error) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/getChatMember", config.Cfg.TelegramBotToken)
+	jsonData, err := sonic.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[types.TelegramChatMember]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, err
+	}
+	if !resBody.Ok {
+		return nil, &TelegramError{Method: "getChatMember", Description: resBody.Description}
+	}
+
+	return &resBody.Result, nil
+}
+
+type GetFileParams struct {
+	FileId string `json:"file_id"`
+}
+
+func GetFile(ctx context.Context, params *GetFileParams) (*types.TelegramFile, error) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/getFile", config.Cfg.TelegramBotToken)
+	jsonData, err := sonic.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[types.TelegramFile]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, err
+	}
+	if !resBody.Ok {
+		return nil, &TelegramError{Method: "getFile", Description: resBody.Description}
+	}
+
+	return &resBody.Result, nil
+}
+
+// DownloadFile downloads a file returned by GetFile, reading at most maxSize bytes
+func DownloadFile(ctx context.Context, file *types.TelegramFile, maxSize int64) ([]byte, error) {
+	url := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", config.Cfg.TelegramBotToken, file.FilePath)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: unexpected status code %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, maxSize))
+}
+
+type SendDocumentParams struct {
+	ChatId    int64
+	FileName  string
+	Content   []byte
+	Caption   string
+	ParseMode telegramParseMode
+}
+
+// SendDocument uploads a file, which can't be done in the webhook response
+func SendDocument(ctx context.Context, params *SendDocumentParams) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", config.Cfg.TelegramBotToken)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("chat_id", strconv.FormatInt(params.ChatId, 10)); err != nil {
+		return err
+	}
+	if params.Caption != "" {
+		if err := writer.WriteField("caption", params.Caption); err != nil {
+			return err
+		}
+		if err := writer.WriteField("parse_mode", string(params.ParseMode)); err != nil {
+			return err
+		}
+	}
+	part, err := writer.CreateFormFile("document", params.FileName)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(params.Content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var resBody telegramResponse[types.TelegramMessage]
+	if err := sonic.ConfigDefault.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return err
+	}
+	if !resBody.Ok {
+		return &TelegramError{Method: "sendDocument", Description: resBody.Description}
+	}
+
 	return nil
 }
@@ -14,10 +14,98 @@ const (
 	TelegramParseModeMarkdownV2 telegramParseMode = "MarkdownV2"
 )
 
+const (
+	TelegramChatTypePrivate    = "private"
+	TelegramChatTypeGroup      = "group"
+	TelegramChatTypeSupergroup = "supergroup"
+	TelegramChatTypeChannel    = "channel"
+)
+
+const (
+	TelegramChatMemberStatusCreator       = "creator"
+	TelegramChatMemberStatusAdministrator = "administrator"
+)
+
 type TelegramUpdate struct {
 	UpdateId      int64                 `json:"update_id"`
 	Message       TelegramMessage       `json:"message"`
+	ChannelPost   TelegramMessage       `json:"channel_post"`
 	CallbackQuery TelegramCallbackQuery `json:"callback_query"`
+	InlineQuery   TelegramInlineQuery   `json:"inline_query"`
+}
+
+// NormalizeChannelPost treats a channel post as a message sent by the channel itself,
+// only channel administrators can post so it carries the same rights
+func (u *TelegramUpdate) NormalizeChannelPost() {
+	if u.ChannelPost.MessageId == 0 {
+		return
+	}
+	u.Message = u.ChannelPost
+	u.Message.From = TelegramUser{
+		Id:        u.ChannelPost.Chat.Id,
+		FirstName: u.ChannelPost.Chat.Title,
+		Username:  u.ChannelPost.Chat.Username,
+	}
+}
+
+// ChatId returns the chat where the update happened
+func (u TelegramUpdate) ChatId() int64 {
+	if u.CallbackQuery.Id != "" {
+		return u.CallbackQuery.Message.Chat.Id
+	}
+	return u.Message.Chat.Id
+}
+
+// UserId returns the user who sent the update
+func (u TelegramUpdate) UserId() int64 {
+	if u.InlineQuery.Id != "" {
+		return u.InlineQuery.From.Id
+	}
+	if u.CallbackQuery.Id != "" {
+		return u.CallbackQuery.From.Id
+	}
+	return u.Message.From.Id
+}
+
+// SerialKey returns the key of the updates that must be processed one at a time: the chat,
+// or the user for updates without a chat (e.g. inline queries)
+func (u TelegramUpdate) SerialKey() int64 {
+	if chatId := u.ChatId(); chatId != 0 {
+		return chatId
+	}
+	return u.UserId()
+}
+
+// LanguageCode returns the IETF language tag of the user's client, if any
+func (u TelegramUpdate) LanguageCode() string {
+	if u.InlineQuery.Id != "" {
+		return u.InlineQuery.From.LanguageCode
+	}
+	if u.CallbackQuery.Id != "" {
+		return u.CallbackQuery.From.LanguageCode
+	}
+	return u.Message.From.LanguageCode
+}
+
+// ChatType returns the type of the chat where the update happened
+func (u TelegramUpdate) ChatType() string {
+	if u.CallbackQuery.Id != "" {
+		return u.CallbackQuery.Message.Chat.Type
+	}
+	return u.Message.Chat.Type
+}
+
+// IsPrivateChat reports whether the update comes from a one-on-one chat with the bot
+func (u TelegramUpdate) IsPrivateChat() bool {
+	chatType := u.ChatType()
+	return chatType == "" || chatType == TelegramChatTypePrivate
+}
+
+// IsGroupChat reports whether the update comes from a group, where bots in privacy mode only
+// receive the commands and the replies to their messages
+func (u TelegramUpdate) IsGroupChat() bool {
+	chatType := u.ChatType()
+	return chatType == TelegramChatTypeGroup || chatType == TelegramChatTypeSupergroup
 }
 
 type TelegramResponse struct {
@@ -27,6 +115,7 @@ type TelegramResponse struct {
 	ParseMode          telegramParseMode           `json:"parse_mode,omitempty"`
 	Text               string                      `json:"text,omitempty"`
 	ReplyMarkup        any                         `json:"reply_markup,omitempty"`
+	ReplyParameters    *TelegramReplyParameters    `json:"reply_parameters,omitempty"`
 	LinkPreviewOptions *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
 }
 
@@ -36,47 +125,101 @@ type TelegramMessage struct {
 	From      TelegramUser `json:"from"`
 	Chat      TelegramChat `json:"chat"`
 	Text      string       `json:"text"`
+	// Set if the message is a general file
+	Document TelegramDocument `json:"document"`
+	Caption  string           `json:"caption"`
+}
+
+type TelegramDocument struct {
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type TelegramFile struct {
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size"`
+	// Use https://api.telegram.org/file/bot<token>/<file_path> to download the file
+	FilePath string `json:"file_path"`
 }
 
 type TelegramCallbackQuery struct {
 	Id      string          `json:"id"`
 	From    TelegramUser    `json:"from"`
 	Message TelegramMessage `json:"message"`
-	Data    string          `json:"data"`
+	// Set instead of message if the message was sent via inline mode
+	InlineMessageId string `json:"inline_message_id"`
+	Data            string `json:"data"`
+}
+
+type TelegramInlineQuery struct {
+	Id     string       `json:"id"`
+	From   TelegramUser `json:"from"`
+	Query  string       `json:"query"`
+	Offset string       `json:"offset"`
+}
+
+type TelegramInlineQueryResultArticle struct {
+	Type                string                          `json:"type"` // Always "article"
+	Id                  string                          `json:"id"`
+	Title               string                          `json:"title"`
+	Description         string                          `json:"description,omitempty"`
+	InputMessageContent TelegramInputTextMessageContent `json:"input_message_content"`
+	ReplyMarkup         *TelegramInlineKeyboardMarkup   `json:"reply_markup,omitempty"`
+}
+
+type TelegramInputTextMessageContent struct {
+	MessageText        string                      `json:"message_text"`
+	ParseMode          telegramParseMode           `json:"parse_mode,omitempty"`
+	LinkPreviewOptions *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
 }
 
 type TelegramUser struct {
-	Id        int64  `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
+	Id           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type TelegramChat struct {
 	Id        int64  `json:"id"`
 	Type      string `json:"type"`
+	Title     string `json:"title"`
 	Username  string `json:"username"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 }
 
+type TelegramChatMember struct {
+	Status string       `json:"status"`
+	User   TelegramUser `json:"user"`
+}
+
 type TelegramReplyKeyboardMarkup struct {
 	Keyboard       [][]string `json:"keyboard"`
 	ResizeKeyboard bool       `json:"resize_keyboard"`
 }
 
-var DefaultReplyMarkup = TelegramReplyKeyboardMarkup{
-	Keyboard: [][]string{
-		{"Watch", "Unwatch"},
-		{"Watch List"},
-	},
-	ResizeKeyboard: true,
-}
-
 type TelegramInlineKeyboardMarkup struct {
 	InlineKeyboard [][]TelegramInlineKeyboardButton `json:"inline_keyboard"`
 }
 
+// TelegramForceReply opens the reply to the message. Selective limits it to the sender of the
+// message replied to.
+type TelegramForceReply struct {
+	ForceReply bool `json:"force_reply"`
+	Selective  bool `json:"selective,omitempty"`
+}
+
+type TelegramReplyParameters struct {
+	MessageId                int64 `json:"message_id"`
+	AllowSendingWithoutReply bool  `json:"allow_sending_without_reply,omitempty"`
+}
+
 type TelegramInlineKeyboardButton struct {
 	Text         string `json:"text"`
 	CallbackData string `json:"callback_data"`